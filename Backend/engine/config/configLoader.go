@@ -3,6 +3,7 @@
 package config
 
 import (
+	"errors"
 	"log"
 	"os"
 
@@ -24,3 +25,14 @@ func GetEnv(key, defaultVal string) string {
 	}
 	return defaultVal
 }
+
+// PonovnoUcitajEnv - ponovno čita .env i prepisuje postojeće vrijednosti (rotacija tajni bez restarta).
+// Ako .env ne postoji (npr. varijable dolaze iz docker-compose), ništa se ne mijenja.
+func PonovnoUcitajEnv() error {
+	err := godotenv.Overload()
+	if errors.Is(err, os.ErrNotExist) {
+		log.Println(".env ne postoji, koriste se postojeće varijable okruženja")
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// tajneVarijable - env varijable čije se vrijednosti nikad ne smiju pojaviti u logovima
+var tajneVarijable = []string{
+	"GEMINI_API_KEY",
+	"GEMINI_API_KEYS",
+	"OPENAI_API_KEY",
+	"API_SECRET",
+	"REDIS_PASSWORD",
+}
+
+// minDuljinaTajne - kraće vrijednosti se ne zamjenjuju jer bi pogađale običan tekst
+const minDuljinaTajne = 6
+
+const redaktirano = "[REDACTED]"
+
+// uzorciTajni - tajne prepoznatljive po obliku, i kad nisu iz konfiguracije
+var uzorciTajni = []struct {
+	re   *regexp.Regexp
+	zamj string
+}{
+	{regexp.MustCompile(`AIza[0-9A-Za-z_\-]{35}`), redaktirano},                                // Google API ključ
+	{regexp.MustCompile(`([?&](?:key|api_key|apikey|token)=)[^&\s"']+`), "${1}" + redaktirano}, // ključ u query stringu
+	{regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`), "${1}" + redaktirano},
+	{regexp.MustCompile(`(?i)(x-goog-api-key:\s*)\S+`), "${1}" + redaktirano},
+	{regexp.MustCompile(`(mongodb(?:\+srv)?://[^:/@\s]+:)[^@\s]+@`), "${1}" + redaktirano + "@"}, // lozinka u connection stringu
+}
+
+// vrijednostiTajni - trenutne vrijednosti tajnih varijabli (ključevi se mogu rotirati bez restarta)
+func vrijednostiTajni() []string {
+	var tajne []string
+	for _, k := range tajneVarijable {
+		for _, v := range strings.Split(os.Getenv(k), ",") {
+			v = strings.Trim(strings.TrimSpace(v), `"'`)
+			if len(v) >= minDuljinaTajne {
+				tajne = append(tajne, v)
+			}
+		}
+	}
+	return tajne
+}
+
+// RedaktirajTajne - zamjenjuje API ključeve, lozinke i tokene u tekstu oznakom [REDACTED]
+func RedaktirajTajne(s string) string {
+	for _, t := range vrijednostiTajni() {
+		s = strings.ReplaceAll(s, t, redaktirano)
+	}
+	for _, u := range uzorciTajni {
+		s = u.re.ReplaceAllString(s, u.zamj)
+	}
+	return s
+}
+
+// RedaktirajURI - connection string bez lozinke, za logove
+func RedaktirajURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return RedaktirajTajne(uri)
+	}
+	return u.Redacted()
+}
+
+// redaktirajuciWriter - propušta log zapise kroz RedaktirajTajne
+type redaktirajuciWriter struct {
+	w io.Writer
+}
+
+func (r redaktirajuciWriter) Write(p []byte) (int, error) {
+	if _, err := r.w.Write([]byte(RedaktirajTajne(string(p)))); err != nil {
+		return 0, err
+	}
+	// Pozivatelj očekuje duljinu izvornog zapisa
+	return len(p), nil
+}
+
+// RedaktirajuciWriter - omotač za izlaz loggera (log, logrus, gin) koji uklanja tajne
+func RedaktirajuciWriter(w io.Writer) io.Writer {
+	return redaktirajuciWriter{w: w}
+}
@@ -15,7 +15,7 @@ var MongoClient *mongo.Client
 // InitMongo initializes MongoDB client
 func InitMongo() {
 	mongoURI := config.GetEnv("MONGO_URI", "mongodb://localhost:27017")
-	log.Printf("Connecting to MongoDB with URI: %s", mongoURI)
+	log.Printf("Connecting to MongoDB with URI: %s", config.RedaktirajURI(mongoURI))
 
 	clientOptions := options.Client().ApplyURI(mongoURI)
 
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/ddobren/eduformacije/models"
+	"github.com/ddobren/eduformacije/services"
+	"github.com/gin-gonic/gin"
+)
+
+const maxDanaPotrosnje = 90
+
+// GetAIPotrosnjaHandler - GET /api/v1/admin/ai/potrosnja?dana=7
+func GetAIPotrosnjaHandler(c *gin.Context) {
+	dana, err := strconv.Atoi(c.DefaultQuery("dana", "7"))
+	if err != nil || dana < 1 || dana > maxDanaPotrosnje {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parametar dana mora biti između 1 i 90"})
+		return
+	}
+
+	sazetak, err := services.SazetakPotrosnje(c.Request.Context(), dana)
+	if err != nil {
+		log.Printf("Greška pri dohvaćanju AI potrošnje: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri dohvaćanju AI potrošnje"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dani": sazetak})
+}
+
+// PostAPIKljucHandler - POST /api/v1/admin/api-kljucevi
+// Ključ se vraća samo u ovom odgovoru; kasnije je vidljiv samo prefiks.
+func PostAPIKljucHandler(c *gin.Context) {
+	var req models.APIKljucRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Naziv ključa je obavezan"})
+		return
+	}
+
+	kljuc, k, err := services.KreirajAPIKljuc(c.Request.Context(), req)
+	if errors.Is(err, services.ErrNeispravanAPIKljucZahtjev) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Greška pri stvaranju API ključa: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri stvaranju API ključa"})
+		return
+	}
+
+	auditDetalji(c, "id", k.Id)
+	log.Printf("Stvoren API ključ %s (%s)", k.Id, k.Naziv)
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, models.NoviAPIKljucResponse{Kljuc: kljuc, APIKljuc: k})
+}
+
+// GetAPIKljuceviHandler - GET /api/v1/admin/api-kljucevi
+func GetAPIKljuceviHandler(c *gin.Context) {
+	kljucevi, err := services.PopisAPIKljuceva(c.Request.Context())
+	if err != nil {
+		log.Printf("Greška pri dohvaćanju API ključeva: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri dohvaćanju API ključeva"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"kljucevi": kljucevi})
+}
+
+// DeleteAPIKljucHandler - DELETE /api/v1/admin/api-kljucevi/:id
+func DeleteAPIKljucHandler(c *gin.Context) {
+	id := c.Param("id")
+	auditDetalji(c, "id", id)
+	err := services.OpozoviAPIKljuc(c.Request.Context(), id)
+	if errors.Is(err, services.ErrAPIKljucNePostoji) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API ključ ne postoji ili je već opozvan"})
+		return
+	}
+	if err != nil {
+		log.Printf("Greška pri opozivu API ključa %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri opozivu API ključa"})
+		return
+	}
+
+	log.Printf("Opozvan API ključ %s", id)
+	c.Status(http.StatusNoContent)
+}
+
+// GetAPIKljucKoristenjeHandler - GET /api/v1/admin/api-kljucevi/:id/koristenje?dana=30
+func GetAPIKljucKoristenjeHandler(c *gin.Context) {
+	dana, err := strconv.Atoi(c.DefaultQuery("dana", "30"))
+	if err != nil || dana < 1 || dana > maxDanaPotrosnje {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parametar dana mora biti između 1 i 90"})
+		return
+	}
+
+	koristenje, err := services.KoristenjeKljuca(c.Request.Context(), c.Param("id"), dana)
+	if err != nil {
+		log.Printf("Greška pri dohvaćanju korištenja API ključa: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri dohvaćanju korištenja API ključa"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"dani": koristenje})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ddobren/eduformacije/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Rute koje rade i u načinu održavanja: administracija (da se može isključiti), izdavanje
+// admin tokena i javni ključevi za provjeru tokena
+var rutePrijeOdrzavanja = []string{"/api/v1/admin", "/api/v1/auth/token", "/.well-known/"}
+
+// OdrzavanjeMiddleware - dok je uključen način održavanja, ostale rute vraćaju 503
+func OdrzavanjeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, prefiks := range rutePrijeOdrzavanja {
+			if strings.HasPrefix(c.Request.URL.Path, prefiks) {
+				c.Next()
+				return
+			}
+		}
+
+		stanje, err := services.StanjeOdrzavanja(c.Request.Context())
+		if err != nil {
+			// Bez Redisa ne znamo stanje; radije služimo zahtjeve nego da sve bude nedostupno
+			log.Printf("Greška pri provjeri načina održavanja: %v", err)
+		}
+		if !stanje.Ukljuceno {
+			c.Next()
+			return
+		}
+
+		poruka := stanje.Poruka
+		if poruka == "" {
+			poruka = "Servis je privremeno u održavanju. Pokušaj ponovno za nekoliko minuta."
+		}
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": poruka, "razlog": "odrzavanje"})
+	}
+}
+
+// auditDetalji - handler dodaje podatke o akciji u audit zapis (npr. koji je posao pokrenut)
+func auditDetalji(c *gin.Context, kljuc string, vrijednost interface{}) {
+	detalji, _ := c.Get("auditDetalji")
+	d, ok := detalji.(map[string]interface{})
+	if !ok {
+		d = map[string]interface{}{}
+		c.Set("auditDetalji", d)
+	}
+	d[kljuc] = vrijednost
+}
+
+// AuditMiddleware - zapisuje svaku administratorsku akciju (sve osim GET zahtjeva), i odbijene.
+// Mora biti iza JWTAuthMiddleware, a ispred ZahtijevajUlogu da se bilježe i pokušaji bez ovlasti.
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			return
+		}
+
+		claims, _ := c.MustGet("jwtClaims").(jwt.MapClaims)
+		akter, _ := claims["sub"].(string)
+		zapis := services.AuditZapis{
+			Vrijeme: time.Now().UTC(),
+			Akter:   akter,
+			IP:      c.ClientIP(),
+			Metoda:  c.Request.Method,
+			Ruta:    c.FullPath(),
+			Putanja: c.Request.URL.Path,
+			Status:  c.Writer.Status(),
+		}
+		if detalji, ok := c.Get("auditDetalji"); ok {
+			zapis.Detalji, _ = detalji.(map[string]interface{})
+		}
+		services.ZabiljeziAudit(zapis)
+	}
+}
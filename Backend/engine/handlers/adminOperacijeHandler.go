@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/ddobren/eduformacije/models"
+	"github.com/ddobren/eduformacije/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const maxAuditZapisa = 500
+
+// adminSub - "sub" administratora koji je pokrenuo akciju
+func adminSub(c *gin.Context) string {
+	claims, _ := c.MustGet("jwtClaims").(jwt.MapClaims)
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// GetIngestHandler - GET /api/v1/admin/ingest
+// Svi ingest poslovi sa zadnjim rezultatom
+func GetIngestHandler(c *gin.Context) {
+	statusi, err := services.StatusIngesta(c.Request.Context())
+	if err != nil {
+		log.Printf("Greška pri dohvaćanju statusa ingesta: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri dohvaćanju statusa ingesta"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"poslovi": statusi})
+}
+
+// PostIngestHandler - POST /api/v1/admin/ingest/:posao
+// Pokreće posao u pozadini; rezultat je na GET /admin/ingest
+func PostIngestHandler(c *gin.Context) {
+	posao := c.Param("posao")
+	auditDetalji(c, "posao", posao)
+
+	err := services.PokreniIngest(posao, adminSub(c))
+	switch {
+	case errors.Is(err, services.ErrNepoznatPosao):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Nepoznat ingest posao: " + posao})
+		return
+	case errors.Is(err, services.ErrPosaoVecRadi):
+		c.JSON(http.StatusConflict, gin.H{"error": "Posao " + posao + " se već izvršava"})
+		return
+	case err != nil:
+		log.Printf("Greška pri pokretanju ingesta %s: %v", posao, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri pokretanju ingesta"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"posao": posao, "status": "pokrenuto"})
+}
+
+// PostOcistiCacheHandler - POST /api/v1/admin/cache/ocisti?vrsta=sve
+// vrsta: sugestije, api-kljucevi, indeksi ili sve
+func PostOcistiCacheHandler(c *gin.Context) {
+	vrsta := c.DefaultQuery("vrsta", "sve")
+	auditDetalji(c, "vrsta", vrsta)
+
+	obrisano, err := services.OcistiCache(c.Request.Context(), vrsta)
+	if errors.Is(err, services.ErrNepoznataVrstaCachea) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parametar vrsta mora biti sugestije, api-kljucevi, indeksi ili sve"})
+		return
+	}
+	if err != nil {
+		log.Printf("Greška pri čišćenju cachea (%s): %v", vrsta, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri čišćenju cachea"})
+		return
+	}
+
+	auditDetalji(c, "obrisano", obrisano)
+	c.JSON(http.StatusOK, gin.H{"obrisano": obrisano})
+}
+
+// PostPonovnoUcitajTajneHandler - POST /api/v1/admin/tajne/ponovno-ucitaj
+// Učitava rotirane tajne (.env, registar klijenata, JWT ključevi) bez restarta
+func PostPonovnoUcitajTajneHandler(c *gin.Context) {
+	rezultati := services.PonovnoUcitajTajne()
+
+	status := http.StatusOK
+	for _, r := range rezultati {
+		auditDetalji(c, r.Dio, r.Uspjeh)
+		if !r.Uspjeh {
+			status = http.StatusInternalServerError
+		}
+	}
+	c.JSON(status, gin.H{"rezultati": rezultati})
+}
+
+// GetOdrzavanjeHandler - GET /api/v1/admin/odrzavanje
+func GetOdrzavanjeHandler(c *gin.Context) {
+	stanje, err := services.StanjeOdrzavanja(c.Request.Context())
+	if err != nil {
+		log.Printf("Greška pri dohvaćanju načina održavanja: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri dohvaćanju načina održavanja"})
+		return
+	}
+	c.JSON(http.StatusOK, stanje)
+}
+
+// PutOdrzavanjeHandler - PUT /api/v1/admin/odrzavanje
+func PutOdrzavanjeHandler(c *gin.Context) {
+	var req models.OdrzavanjeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Polje ukljuceno je obavezno"})
+		return
+	}
+	auditDetalji(c, "ukljuceno", *req.Ukljuceno)
+
+	stanje, err := services.PostaviOdrzavanje(c.Request.Context(), *req.Ukljuceno, req.Poruka, adminSub(c))
+	if err != nil {
+		log.Printf("Greška pri promjeni načina održavanja: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri promjeni načina održavanja"})
+		return
+	}
+
+	log.Printf("Način održavanja: ukljuceno=%t (%s)", stanje.Ukljuceno, adminSub(c))
+	c.JSON(http.StatusOK, stanje)
+}
+
+// GetAuditHandler - GET /api/v1/admin/audit?limit=100
+func GetAuditHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > maxAuditZapisa {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parametar limit mora biti između 1 i 500"})
+		return
+	}
+
+	zapisi, err := services.ZadnjiAuditZapisi(c.Request.Context(), limit)
+	if err != nil {
+		log.Printf("Greška pri dohvaćanju audit zapisa: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri dohvaćanju audit zapisa"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"zapisi": zapisi})
+}
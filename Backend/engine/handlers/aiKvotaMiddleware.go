@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/ddobren/eduformacije/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AIKvotaMiddleware - token bucket i dnevni limit AI poziva po klijentu (vidi services.ProvjeriAIKvotu).
+// Uz dozvoliOffline zahtjevi s ?nacin=offline ne troše kvotu jer ne pozivaju model; samo rute
+// preporuka poštuju taj parametar, savjetnik uvijek poziva model.
+func AIKvotaMiddleware(dozvoliOffline bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if dozvoliOffline && c.Query("nacin") == services.NacinOffline {
+			c.Next()
+			return
+		}
+
+		klijent := services.KlijentIzKonteksta(c.Request.Context())
+		kvota, err := services.ProvjeriAIKvotu(c.Request.Context(), klijent)
+		if err != nil {
+			// Bez Redisa ne možemo brojati; ne blokiramo korisnika, globalni limiter i dalje vrijedi
+			log.Printf("Greška pri provjeri AI kvote za %s: %v", klijent, err)
+			c.Next()
+			return
+		}
+
+		if kvota.PreostaloDanas >= 0 {
+			c.Header("X-AI-Kvota-Preostalo", strconv.Itoa(kvota.PreostaloDanas))
+		}
+
+		if !kvota.Dozvoljeno {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(kvota.PokusajZa.Seconds()))))
+			if kvota.DnevniLimit {
+				log.Printf("AI kvota: dnevni limit potrošen za %s", klijent)
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+					"error":  "Potrošen je dnevni broj AI preporuka. Pokušaj ponovno sutra ili koristi preporuke bez AI-ja.",
+					"razlog": "dnevni-limit",
+				})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":  "Previše AI zahtjeva u kratkom vremenu. Pričekaj malo pa pokušaj ponovno.",
+				"razlog": "kvota",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ddobren/eduformacije/models"
+	"github.com/ddobren/eduformacije/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const apiKljucHeader = "X-API-Key"
+
+// apiKljucIzZahtjeva - provjereni ključ iz X-API-Key zaglavlja (nil ako ga nema).
+// Rezultat se pamti u kontekstu jer ga trebaju i rate limiter i autentikacija.
+func apiKljucIzZahtjeva(c *gin.Context) (*models.APIKljuc, error) {
+	if k, ok := c.Get("apiKljuc"); ok {
+		return k.(*models.APIKljuc), nil
+	}
+	vrijednost := c.GetHeader(apiKljucHeader)
+	if vrijednost == "" {
+		return nil, nil
+	}
+	k, err := services.ProvjeriAPIKljuc(c.Request.Context(), vrijednost)
+	if err != nil {
+		return nil, err
+	}
+	c.Set("apiKljuc", k)
+	return k, nil
+}
+
+// autentificirajAPIKljucem - alternativa Bearer tokenu za vanjske integratore. Postavlja iste
+// claimove kao token ("sub" i "scope") pa ZahtijevajScope i limiti rade jednako; uloga se ne
+// dodjeljuje, tako da ključ nikad nema pristup /admin rutama.
+func autentificirajAPIKljucem(c *gin.Context) {
+	k, err := apiKljucIzZahtjeva(c)
+	if errors.Is(err, services.ErrNepoznatAPIKljuc) {
+		log.Printf("API ključ odbijen: nepoznat ili opozvan (ip=%s)", c.ClientIP())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Nevažeći API ključ"})
+		return
+	}
+	if err != nil {
+		log.Printf("Greška pri provjeri API ključa: %v", err)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Provjera API ključa trenutno nije moguća"})
+		return
+	}
+
+	if !services.APIKljucDozvoljavaOrigin(k, c.GetHeader("Origin")) {
+		log.Printf("API ključ %s odbijen: origin %q nije dopušten", k.Id, c.GetHeader("Origin"))
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Origin nije dopušten za ovaj API ključ"})
+		return
+	}
+	if !services.APIKljucDozvoljavaRutu(k, c.FullPath()) {
+		log.Printf("API ključ %s odbijen: ruta %s nije dopuštena", k.Id, c.FullPath())
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Ruta nije dopuštena za ovaj API ključ"})
+		return
+	}
+
+	if k.DnevniLimit > 0 {
+		danas, err := services.KoristenjeKljuca(c.Request.Context(), k.Id, 1)
+		if err != nil {
+			// Brojač nije kritičan; bez njega se dnevni limit ne može provjeriti, ali zahtjev prolazi
+			log.Printf("Greška pri čitanju korištenja API ključa %s: %v", k.Id, err)
+		} else if danas[0].Zahtjeva >= int64(k.DnevniLimit) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(services.DoPonoci().Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Dnevni limit API ključa je potrošen", "razlog": "dnevni-limit"})
+			return
+		}
+	}
+
+	claims := jwt.MapClaims{
+		"sub":   services.APIKljucSubPrefix + k.Id,
+		"scope": strings.Join(k.Scope, " "),
+	}
+	c.Set("jwtClaims", claims)
+	c.Request = c.Request.WithContext(services.SKlijentom(c.Request.Context(), klijentIzTokena(claims, c.ClientIP())))
+	c.Next()
+
+	// Broje se samo zahtjevi koji su prošli; odbijeni zbog limita (npr. AI kvote) ne troše dnevni limit
+	if c.Writer.Status() == http.StatusTooManyRequests {
+		return
+	}
+	if _, err := services.ZabiljeziKoristenjeKljuca(context.WithoutCancel(c.Request.Context()), k.Id); err != nil {
+		log.Printf("Greška pri bilježenju korištenja API ključa %s: %v", k.Id, err)
+	}
+}
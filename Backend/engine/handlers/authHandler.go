@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ddobren/eduformacije/models"
+	"github.com/ddobren/eduformacije/services"
+	"github.com/gin-gonic/gin"
+)
+
+// PostTokenHandler - POST /api/v1/auth/token
+// Mijenja client_id i client_secret registriranog klijenta za kratkotrajni pristupni token.
+func PostTokenHandler(c *gin.Context) {
+	var req models.TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nevažeći zahtjev za token"})
+		return
+	}
+	if id, tajna, ok := c.Request.BasicAuth(); ok {
+		req.ClientId, req.ClientSecret = id, tajna
+	}
+
+	if req.GrantType != "" && req.GrantType != "client_credentials" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Podržan je samo grant_type client_credentials"})
+		return
+	}
+	if req.ClientId == "" || req.ClientSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "client_id i client_secret su obavezni"})
+		return
+	}
+
+	klijent, err := services.ProvjeriKlijenta(req.ClientId, req.ClientSecret)
+	if errors.Is(err, services.ErrNeispravniPodaciKlijenta) {
+		log.Printf("Token odbijen: neispravni podaci klijenta %q (ip=%s)", req.ClientId, c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Neispravni podaci klijenta"})
+		return
+	}
+
+	scope, err := services.OdaberiScope(klijent, req.Scope)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, istek, err := services.GenerateJWT(klijent, scope)
+	if err != nil {
+		log.Printf("Greška pri izdavanju tokena za %s: %v", klijent.Id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri izdavanju tokena"})
+		return
+	}
+
+	log.Printf("Izdan token za klijenta %s (scope=%s)", klijent.Id, strings.Join(scope, " "))
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(math.Round(time.Until(istek).Seconds())),
+		Scope:       strings.Join(scope, " "),
+	})
+}
+
+// GetIzazovHandler - GET /api/v1/auth/izazov
+// Izazov za anonimnu sesiju: preglednik traži rješenje takvo da SHA-256(izazov + ":" + rjesenje)
+// počinje s barem "tezina" nul-bitova.
+func GetIzazovHandler(c *gin.Context) {
+	izazov, err := services.NoviIzazov(c.GetHeader("Origin"))
+	if errors.Is(err, services.ErrOriginNijeDopusten) {
+		log.Printf("Izazov odbijen: origin %q nije dopušten (ip=%s)", c.GetHeader("Origin"), c.ClientIP())
+		c.JSON(http.StatusForbidden, gin.H{"error": "Origin nije dopušten"})
+		return
+	}
+	if err != nil {
+		log.Printf("Greška pri izdavanju izazova: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri izdavanju izazova"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, izazov)
+}
+
+// PostAnonimnaSesijaHandler - POST /api/v1/auth/sesija
+// Mijenja riješeni izazov za kratkotrajni token anonimne sesije.
+func PostAnonimnaSesijaHandler(c *gin.Context) {
+	var req models.AnonimnaSesijaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "izazov i rjesenje su obavezni"})
+		return
+	}
+
+	sesija, err := services.OtvoriAnonimnuSesiju(c.Request.Context(), req.Izazov, req.Rjesenje, c.GetHeader("Origin"), c.ClientIP())
+	switch {
+	case errors.Is(err, services.ErrNeispravanIzazov), errors.Is(err, services.ErrKrivoRjesenje),
+		errors.Is(err, services.ErrIzazovIstekao), errors.Is(err, services.ErrIzazovIskoristen):
+		log.Printf("Anonimna sesija odbijena: %v (ip=%s)", err, c.ClientIP())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrPreviseSesija):
+		log.Printf("Anonimna sesija odbijena: %v (ip=%s)", err, c.ClientIP())
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(services.DoSljedecegSata().Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrOriginNijeDopusten):
+		log.Printf("Anonimna sesija odbijena: origin %q (ip=%s)", c.GetHeader("Origin"), c.ClientIP())
+		c.JSON(http.StatusForbidden, gin.H{"error": "Origin nije dopušten"})
+		return
+	case err != nil:
+		log.Printf("Greška pri otvaranju anonimne sesije: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Greška pri otvaranju sesije"})
+		return
+	}
+
+	scope := services.ScopeCitanjeSkola + " " + services.ScopeAISugestije
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.TokenResponse{
+		AccessToken: sesija.Token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(math.Round(time.Until(sesija.Istek).Seconds())),
+		Scope:       scope,
+	})
+}
+
+// GetJWKSHandler - GET /.well-known/jwks.json
+// Javni ključevi za provjeru tokena (partnerski servisi ne trebaju API_SECRET).
+// Sadrži i stare ključeve koji vrijede samo za provjeru, dok ne isteknu tokeni potpisani njima.
+func GetJWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": services.JWKS()})
+}
@@ -2,22 +2,76 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/ddobren/eduformacije/services"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
 )
 
-// CustomRateLimiter - Middleware za ograničavanje zahtjeva
+// CustomRateLimiter - Middleware za ograničavanje zahtjeva po IP adresi. Zahtjevi s ispravnim
+// API ključem imaju vlastiti limit (LimitPoSekundi ključa) umjesto limita IP adrese.
 func CustomRateLimiter(rdb *redis.Client, limit int, window time.Duration) gin.HandlerFunc {
+	return rateLimiter(rdb, window, ipIliAPIKljucLimit(limit))
+}
+
+// ipIliAPIKljucLimit - limit ključa samo kad se zahtjev zaista autentificira ključem; uz
+// Authorization zaglavlje JWTAuthMiddleware ključ ignorira, pa vrijedi limit IP adrese
+func ipIliAPIKljucLimit(limit int) func(c *gin.Context) (string, int) {
+	return func(c *gin.Context) (string, int) {
+		if c.GetHeader("Authorization") == "" {
+			k, err := apiKljucIzZahtjeva(c)
+			if err != nil && !errors.Is(err, services.ErrNepoznatAPIKljuc) {
+				log.Printf("Greška pri provjeri API ključa za rate limit: %v", err)
+			}
+			if k != nil {
+				return "rate_limiter:apikey:" + k.Id, k.LimitPoSekundi
+			}
+		}
+		return "rate_limiter:" + c.ClientIP(), limit
+	}
+}
+
+// SesijaRateLimiter - ograničavanje po tokenu ("sub" claim), tako da korisnici iza istog
+// NAT-a ne dijele limit. API ključeve već ograničava CustomRateLimiter (limit ključa), pa ih
+// ovdje propušta. Mora biti iza JWTAuthMiddleware.
+func SesijaRateLimiter(rdb *redis.Client, limit int, window time.Duration) gin.HandlerFunc {
+	return rateLimiter(rdb, window, sesijaLimit(limit))
+}
+
+// sesijaLimit - propušta samo zahtjeve autentificirane API ključem (sub s APIKljucSubPrefix)
+func sesijaLimit(limit int) func(c *gin.Context) (string, int) {
+	return func(c *gin.Context) (string, int) {
+		claims, _ := c.MustGet("jwtClaims").(jwt.MapClaims)
+		sub, _ := claims["sub"].(string)
+		if strings.HasPrefix(sub, services.APIKljucSubPrefix) {
+			return "", 0
+		}
+		if sub != "" {
+			return "rate_limiter:sesija:" + sub, limit
+		}
+		return "rate_limiter:" + c.ClientIP(), limit
+	}
+}
+
+// rateLimiter - klizni prozor u Redis sorted setu; kljuc vraća Redis ključ i limit za zahtjev
+// (prazan ključ znači da zahtjev ovaj limiter ne ograničava)
+func rateLimiter(rdb *redis.Client, window time.Duration, kljuc func(c *gin.Context) (string, int)) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.Background()
-		clientIP := c.ClientIP()
 
 		// Redis ključ za praćenje zahtjeva
-		redisKey := "rate_limiter:" + clientIP
+		redisKey, limit := kljuc(c)
+		if redisKey == "" {
+			c.Next()
+			return
+		}
 		now := time.Now().UnixNano() / int64(time.Millisecond) // Vremenska oznaka u milisekundama
 
 		// Dodavanje trenutnog vremena u Redis sorted set
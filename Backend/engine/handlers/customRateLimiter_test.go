@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ddobren/eduformacije/models"
+	"github.com/ddobren/eduformacije/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestIPIliAPIKljucLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kljuc := &models.APIKljuc{Id: "k1", LimitPoSekundi: 100}
+
+	slucajevi := []struct {
+		naziv         string
+		authorization string
+		ocekKljuc     string
+		ocekLimit     int
+	}{
+		{"samo API ključ", "", "rate_limiter:apikey:k1", 100},
+		{"Bearer token uz API ključ", "Bearer token", "rate_limiter:192.0.2.1", 5},
+	}
+	for _, s := range slucajevi {
+		t.Run(s.naziv, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/api/v1/sugestije", nil)
+			c.Request.RemoteAddr = "192.0.2.1:1234"
+			c.Request.Header.Set(apiKljucHeader, "tajni-kljuc")
+			if s.authorization != "" {
+				c.Request.Header.Set("Authorization", s.authorization)
+			}
+			c.Set("apiKljuc", kljuc)
+
+			redisKljuc, limit := ipIliAPIKljucLimit(5)(c)
+			if redisKljuc != s.ocekKljuc || limit != s.ocekLimit {
+				t.Errorf("limit = (%q, %d), očekivano (%q, %d)", redisKljuc, limit, s.ocekKljuc, s.ocekLimit)
+			}
+		})
+	}
+}
+
+func TestSesijaLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	slucajevi := []struct {
+		naziv     string
+		sub       string
+		ocekKljuc string
+	}{
+		{"sesija", "sesija-1", "rate_limiter:sesija:sesija-1"},
+		{"API ključ", services.APIKljucSubPrefix + "k1", ""},
+		{"bez sub", "", "rate_limiter:192.0.2.1"},
+	}
+	for _, s := range slucajevi {
+		t.Run(s.naziv, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/api/v1/sugestije", nil)
+			c.Request.RemoteAddr = "192.0.2.1:1234"
+			c.Request.Header.Set(apiKljucHeader, "tajni-kljuc")
+			// odlučuje sub iz claimova, a ne ključ u kontekstu
+			c.Set("apiKljuc", &models.APIKljuc{Id: "k1"})
+			c.Set("jwtClaims", jwt.MapClaims{"sub": s.sub})
+
+			if redisKljuc, _ := sesijaLimit(10)(c); redisKljuc != s.ocekKljuc {
+				t.Errorf("ključ = %q, očekivano %q", redisKljuc, s.ocekKljuc)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/ddobren/eduformacije/models"
+	"github.com/ddobren/eduformacije/services"
+	"github.com/gin-gonic/gin"
+)
+
+const maxRadiusKm = 200
+
+// parseKoordinatu - čita obavezni float parametar i provjerava raspon
+func parseKoordinatu(c *gin.Context, naziv string, min, max float64) (float64, bool) {
+	v, err := strconv.ParseFloat(c.Query(naziv), 64)
+	// ParseFloat prihvaća "NaN" i "Inf", a NaN prolazi usporedbe s rasponom
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) || v < min || v > max {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nevažeća vrijednost za " + naziv})
+		return 0, false
+	}
+	return v, true
+}
+
+// GetSkoleBlizuHandler - GET /api/v1/srednje-skole/blizu?lat=&lng=&radiusKm=
+// Vraća programe sortirane po udaljenosti; podržava iste filtere kao /srednje-skole i opcionalni limit
+func GetSkoleBlizuHandler(c *gin.Context) {
+	lat, ok := parseKoordinatu(c, "lat", -90, 90)
+	if !ok {
+		return
+	}
+	lng, ok := parseKoordinatu(c, "lng", -180, 180)
+	if !ok {
+		return
+	}
+
+	radiusKm := 10.0
+	if c.Query("radiusKm") != "" {
+		if radiusKm, ok = parseKoordinatu(c, "radiusKm", 0, maxRadiusKm); !ok {
+			return
+		}
+	}
+
+	limit := 100
+	if l := c.Query("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil || v < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "nevažeća vrijednost za limit"})
+			return
+		}
+		limit = v
+	}
+
+	filter, ok := parseSkoleFilter(c)
+	if !ok {
+		return
+	}
+
+	idx, ok := dohvatiSkoleIndex(c)
+	if !ok {
+		return
+	}
+
+	result := []models.SkolaUdaljenost{}
+	for _, s := range idx.Blizu(lat, lng, radiusKm) {
+		if !services.OdgovaraFilteru(&s.Skola, filter) {
+			continue
+		}
+		result = append(result, s)
+		if len(result) == limit {
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetSkoleUOkviruHandler - GET /api/v1/srednje-skole/okvir?minLat=&minLng=&maxLat=&maxLng=
+// Vraća programe unutar pravokutnika (vidljivi dio karte)
+func GetSkoleUOkviruHandler(c *gin.Context) {
+	minLat, ok := parseKoordinatu(c, "minLat", -90, 90)
+	if !ok {
+		return
+	}
+	minLng, ok := parseKoordinatu(c, "minLng", -180, 180)
+	if !ok {
+		return
+	}
+	maxLat, ok := parseKoordinatu(c, "maxLat", minLat, 90)
+	if !ok {
+		return
+	}
+	maxLng, ok := parseKoordinatu(c, "maxLng", minLng, 180)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseSkoleFilter(c)
+	if !ok {
+		return
+	}
+
+	idx, ok := dohvatiSkoleIndex(c)
+	if !ok {
+		return
+	}
+
+	result := []models.Skola{}
+	for _, s := range idx.UOkviru(minLat, minLng, maxLat, maxLng) {
+		if services.OdgovaraFilteru(&s, filter) {
+			result = append(result, s)
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
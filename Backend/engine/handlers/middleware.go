@@ -6,15 +6,19 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/ddobren/eduformacije/config"
+	"github.com/ddobren/eduformacije/services"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWTAuthMiddleware - provjerava JWT u Authorization headeru
+// JWTAuthMiddleware - provjerava JWT u Authorization headeru ili API ključ u X-API-Key headeru
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && c.GetHeader(apiKljucHeader) != "" {
+			autentificirajAPIKljucem(c)
+			return
+		}
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
 			log.Println("Authorization header missing or invalid")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Nevažeći ili nedostajući token"})
@@ -22,15 +26,16 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		secretKey := config.GetEnv("API_SECRET", "")
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Provjera algoritma tokena
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("nevažeći algoritam tokena: %v", token.Header["alg"])
-			}
-			return []byte(secretKey), nil
-		})
+		// Ključ se bira prema kid zaglavlju (RS256/EdDSA) ili je API_SECRET za HS256
+		token, err := jwt.Parse(tokenString, services.KljucZaProvjeru,
+			jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}),
+			// Tokeni bez isteka (npr. stari token ugrađen u frontend) se više ne prihvaćaju
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+			jwt.WithIssuer(services.TokenIssuer()),
+			jwt.WithAudience(services.TokenAudience()),
+		)
 
 		if err != nil || !token.Valid {
 			log.Printf("Invalid token: %v", err)
@@ -38,7 +43,83 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		claims, _ := token.Claims.(jwt.MapClaims)
+		c.Set("jwtClaims", claims)
+		// Identitet klijenta za AI kvote i evidenciju potrošnje
+		c.Request = c.Request.WithContext(services.SKlijentom(c.Request.Context(), klijentIzTokena(claims, c.ClientIP())))
+
 		log.Printf("Token validated successfully for request: %s %s", c.Request.Method, c.Request.URL.Path)
 		c.Next()
 	}
 }
+
+// subIzTokena - "sub" claim tokena (ili API ključa) ili prazan string
+func subIzTokena(c *gin.Context) string {
+	claims, _ := c.MustGet("jwtClaims").(jwt.MapClaims)
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// klijentIzTokena - "sub" (ili "client") iz tokena; kvote i potrošnja vežu se uz token, pa
+// promjena IP adrese ne daje novu kvotu. IP adresa se koristi samo za token bez tih claimova.
+func klijentIzTokena(claims jwt.MapClaims, ip string) string {
+	for _, kljuc := range []string{"sub", "client"} {
+		if v, ok := claims[kljuc].(string); ok && v != "" {
+			return v
+		}
+	}
+	return "ip:" + ip
+}
+
+// ZahtijevajScope - propušta samo tokene čiji "scope" claim (popis odvojen razmakom) sadrži zadani scope
+func ZahtijevajScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := c.MustGet("jwtClaims").(jwt.MapClaims)
+
+		dodijeljeni, _ := claims["scope"].(string)
+		for _, s := range strings.Fields(dodijeljeni) {
+			if s == scope {
+				c.Next()
+				return
+			}
+		}
+
+		log.Printf("Pristup odbijen (potreban scope %s): %s %s", scope, c.Request.Method, c.Request.URL.Path)
+		c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope))
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token nema potrebno dopuštenje: " + scope})
+	}
+}
+
+// ZahtijevajUlogu - propušta samo tokene s claimom "role" (ili "roles") koji sadrži zadanu ulogu
+func ZahtijevajUlogu(uloga string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := c.MustGet("jwtClaims").(jwt.MapClaims)
+
+		imaUlogu := claims["role"] == uloga
+		if uloge, ok := claims["roles"].([]interface{}); ok {
+			for _, u := range uloge {
+				if u == uloga {
+					imaUlogu = true
+				}
+			}
+		}
+
+		if !imaUlogu {
+			log.Printf("Pristup odbijen (potrebna uloga %s): %s %s", uloga, c.Request.Method, c.Request.URL.Path)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Nemate ovlasti za ovu akciju"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Zastarjelo - označava staru rutu zaglavljima Deprecation i Link (RFC 8594) koja upućuju na
+// novu rutu; stara ruta i dalje radi dok je klijenti ne prestanu koristiti
+func Zastarjelo(nasljednik string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log.Printf("Zastarjela ruta %s %s (nova: %s, ip=%s)", c.Request.Method, c.Request.URL.Path, nasljednik, c.ClientIP())
+		c.Header("Deprecation", "true")
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, nasljednik))
+		c.Next()
+	}
+}
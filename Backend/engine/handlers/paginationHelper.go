@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ddobren/eduformacije/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultVelicinaStranice = 50
+	maxVelicinaStranice     = 500
+)
+
+// skolaPolja - json naziv polja (lowercase) -> indeks polja u models.Skola
+var skolaPolja = func() map[string]int {
+	polja := make(map[string]int)
+	t := reflect.TypeOf(models.Skola{})
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		polja[strings.ToLower(tag)] = i
+	}
+	return polja
+}()
+
+// skolaUsporedbe - dozvoljeni ključevi za sortiranje
+var skolaUsporedbe = map[string]func(a, b *models.Skola) int{
+	"kvota":    func(a, b *models.Skola) int { return a.Kvota - b.Kvota },
+	"trajanje": func(a, b *models.Skola) int { return a.Trajanje - b.Trajanje },
+	"skola": func(a, b *models.Skola) int {
+		return strings.Compare(strings.ToLower(a.Skola), strings.ToLower(b.Skola))
+	},
+	"mjesto": func(a, b *models.Skola) int {
+		return strings.Compare(strings.ToLower(a.Mjesto), strings.ToLower(b.Mjesto))
+	},
+	"prag": func(a, b *models.Skola) int { return *a.Prag - *b.Prag },
+}
+
+// paginacijaZatrazena - true ako je klijent poslao barem jedan parametar paginacije
+func paginacijaZatrazena(c *gin.Context) bool {
+	for _, p := range []string{"page", "pageSize", "sort", "fields"} {
+		if _, ok := c.GetQuery(p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sortirajSkole - sortira programe prema "sort" parametru (npr. "Kvota,-Prag")
+func sortirajSkole(skole []models.Skola, sortParam string) error {
+	type kljuc struct {
+		usporedi func(a, b *models.Skola) int
+		nullable bool
+		silazno  bool
+	}
+
+	var kljucevi []kljuc
+	for _, dio := range strings.Split(sortParam, ",") {
+		dio = strings.TrimSpace(dio)
+		if dio == "" {
+			continue
+		}
+		silazno := strings.HasPrefix(dio, "-")
+		naziv := strings.ToLower(strings.TrimPrefix(dio, "-"))
+		usporedi, ok := skolaUsporedbe[naziv]
+		if !ok {
+			return fmt.Errorf("nepodržano polje za sortiranje: %s", dio)
+		}
+		kljucevi = append(kljucevi, kljuc{usporedi: usporedi, nullable: naziv == "prag", silazno: silazno})
+	}
+
+	sort.SliceStable(skole, func(i, j int) bool {
+		a, b := &skole[i], &skole[j]
+		for _, k := range kljucevi {
+			// Programi bez praga uvijek idu na kraj
+			if k.nullable && (a.Prag == nil || b.Prag == nil) {
+				if (a.Prag == nil) == (b.Prag == nil) {
+					continue
+				}
+				return b.Prag == nil
+			}
+			r := k.usporedi(a, b)
+			if r == 0 {
+				continue
+			}
+			if k.silazno {
+				return r > 0
+			}
+			return r < 0
+		}
+		return false
+	})
+	return nil
+}
+
+// projicirajSkole - vraća samo tražena polja (npr. "Skola,Program,Kvota")
+func projicirajSkole(skole []models.Skola, fieldsParam string) ([]map[string]interface{}, error) {
+	t := reflect.TypeOf(models.Skola{})
+
+	var nazivi []string
+	var indeksi []int
+	for _, polje := range strings.Split(fieldsParam, ",") {
+		polje = strings.TrimSpace(polje)
+		if polje == "" {
+			continue
+		}
+		i, ok := skolaPolja[strings.ToLower(polje)]
+		if !ok {
+			return nil, fmt.Errorf("nepoznato polje: %s", polje)
+		}
+		nazivi = append(nazivi, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+		indeksi = append(indeksi, i)
+	}
+
+	result := make([]map[string]interface{}, 0, len(skole))
+	for _, s := range skole {
+		v := reflect.ValueOf(s)
+		red := make(map[string]interface{}, len(indeksi))
+		for k, i := range indeksi {
+			red[nazivi[k]] = v.Field(i).Interface()
+		}
+		result = append(result, red)
+	}
+	return result, nil
+}
+
+// stranicaLink - vraća relativni URL trenutnog zahtjeva s drugim brojem stranice
+func stranicaLink(c *gin.Context, stranica int) *string {
+	u := *c.Request.URL
+	q := u.Query()
+	q.Set("page", strconv.Itoa(stranica))
+	u.RawQuery = q.Encode()
+	link := u.RequestURI()
+	return &link
+}
+
+// paginirajSkole - primjenjuje sort, paginaciju i projekciju te vraća omotnicu odgovora
+func paginirajSkole(c *gin.Context, skole []models.Skola) (*models.StranicaResponse, error) {
+	stranica := 1
+	if p := c.Query("page"); p != "" {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 1 {
+			return nil, fmt.Errorf("nevažeća vrijednost za page")
+		}
+		stranica = v
+	}
+
+	velicina := defaultVelicinaStranice
+	if p := c.Query("pageSize"); p != "" {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 1 || v > maxVelicinaStranice {
+			return nil, fmt.Errorf("pageSize mora biti između 1 i %d", maxVelicinaStranice)
+		}
+		velicina = v
+	}
+
+	if sortParam := c.Query("sort"); sortParam != "" {
+		if err := sortirajSkole(skole, sortParam); err != nil {
+			return nil, err
+		}
+	}
+
+	ukupno := len(skole)
+	// stranice iza kraja su prazne; provjera prije množenja jer ogroman page preljeva int
+	od := ukupno
+	if stranica-1 <= ukupno/velicina {
+		od = min((stranica-1)*velicina, ukupno)
+	}
+	do := od + velicina
+	if do > ukupno {
+		do = ukupno
+	}
+	isjecak := skole[od:do]
+	if isjecak == nil {
+		isjecak = []models.Skola{}
+	}
+
+	resp := &models.StranicaResponse{
+		Ukupno:           ukupno,
+		Stranica:         stranica,
+		VelicinaStranice: velicina,
+		Podaci:           isjecak,
+	}
+
+	if fieldsParam := c.Query("fields"); fieldsParam != "" {
+		projekcija, err := projicirajSkole(isjecak, fieldsParam)
+		if err != nil {
+			return nil, err
+		}
+		resp.Podaci = projekcija
+	}
+
+	if do < ukupno {
+		resp.Sljedeca = stranicaLink(c, stranica+1)
+	}
+	if stranica > 1 {
+		resp.Prethodna = stranicaLink(c, stranica-1)
+	}
+
+	return resp, nil
+}
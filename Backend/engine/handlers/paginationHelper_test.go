@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ddobren/eduformacije/models"
+	"github.com/gin-gonic/gin"
+)
+
+func intPtr(v int) *int { return &v }
+
+// testneSkole - ID-evi 1-5; Prag nedostaje za 2 i 5
+func testneSkole() []models.Skola {
+	return []models.Skola{
+		{SkolaProgramRokId: 1, Skola: "Zrinski", Mjesto: "Zagreb", Kvota: 20, Trajanje: 4, Prag: intPtr(400)},
+		{SkolaProgramRokId: 2, Skola: "anić", Mjesto: "Split", Kvota: 30, Trajanje: 3},
+		{SkolaProgramRokId: 3, Skola: "Basarićek", Mjesto: "split", Kvota: 20, Trajanje: 3, Prag: intPtr(350)},
+		{SkolaProgramRokId: 4, Skola: "Marulić", Mjesto: "Zadar", Kvota: 10, Trajanje: 4, Prag: intPtr(500)},
+		{SkolaProgramRokId: 5, Skola: "Kranjčević", Mjesto: "Osijek", Kvota: 30, Trajanje: 4},
+	}
+}
+
+func idevi(skole []models.Skola) string {
+	var ids []string
+	for _, s := range skole {
+		ids = append(ids, strconv.Itoa(s.SkolaProgramRokId))
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestSortirajSkole(t *testing.T) {
+	slucajevi := []struct {
+		sort string
+		ocek string
+	}{
+		{"Kvota", "4,1,3,2,5"},
+		{"-Kvota", "2,5,1,3,4"},
+		{"kvota,-trajanje", "4,1,3,5,2"},
+		{"Skola", "2,3,5,4,1"}, // bez obzira na velika i mala slova
+		{"Mjesto,Kvota", "5,3,2,4,1"},
+		{"Prag", "3,1,4,2,5"},  // bez praga na kraju
+		{"-Prag", "4,1,3,2,5"}, // i kod silaznog sortiranja
+		{"Trajanje,Prag", "3,2,1,4,5"},
+		{" kvota , ", "4,1,3,2,5"},
+		{"", "1,2,3,4,5"},
+	}
+	for _, s := range slucajevi {
+		t.Run(s.sort, func(t *testing.T) {
+			skole := testneSkole()
+			if err := sortirajSkole(skole, s.sort); err != nil {
+				t.Fatal(err)
+			}
+			if dobiveno := idevi(skole); dobiveno != s.ocek {
+				t.Errorf("sort=%q: %s, očekivano %s", s.sort, dobiveno, s.ocek)
+			}
+		})
+	}
+}
+
+func TestSortirajSkoleNepoznatoPolje(t *testing.T) {
+	for _, sort := range []string{"Program", "-nepostoji", "Kvota,Zupanija"} {
+		if err := sortirajSkole(testneSkole(), sort); err == nil {
+			t.Errorf("sort=%q: očekivana greška", sort)
+		}
+	}
+}
+
+func TestProjicirajSkole(t *testing.T) {
+	redovi, err := projicirajSkole(testneSkole()[:1], "skola, KVOTA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(redovi) != 1 || len(redovi[0]) != 2 {
+		t.Fatalf("projekcija = %v", redovi)
+	}
+	for polje, ocek := range map[string]interface{}{"Skola": "Zrinski", "Kvota": 20} {
+		if redovi[0][polje] != ocek {
+			t.Errorf("%s = %v, očekivano %v", polje, redovi[0][polje], ocek)
+		}
+	}
+
+	if _, err := projicirajSkole(testneSkole(), "Skola,Lozinka"); err == nil {
+		t.Error("očekivana greška za nepoznato polje")
+	}
+}
+
+func TestPaginirajSkole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	slucajevi := []struct {
+		upit      string
+		ocek      string
+		sljedeca  string
+		prethodna string
+		greska    bool
+	}{
+		{upit: "", ocek: "1,2,3,4,5"},
+		{upit: "pageSize=2", ocek: "1,2", sljedeca: "page=2&pageSize=2"},
+		{upit: "page=2&pageSize=2", ocek: "3,4", sljedeca: "page=3&pageSize=2", prethodna: "page=1&pageSize=2"},
+		{upit: "page=3&pageSize=2", ocek: "5", prethodna: "page=2&pageSize=2"},
+		{upit: "page=9&pageSize=2", ocek: "", prethodna: "page=8&pageSize=2"},
+		{upit: "page=9223372036854775807&pageSize=100", ocek: "", prethodna: "page=9223372036854775806&pageSize=100"},
+		{upit: "pageSize=2&sort=-Kvota", ocek: "2,5", sljedeca: "page=2&pageSize=2&sort=-Kvota"},
+		{upit: "page=0", greska: true},
+		{upit: "page=x", greska: true},
+		{upit: "pageSize=0", greska: true},
+		{upit: "pageSize=501", greska: true},
+		{upit: "sort=Program", greska: true},
+	}
+	for _, s := range slucajevi {
+		t.Run(s.upit, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/api/v1/srednje-skole?"+s.upit, nil)
+
+			resp, err := paginirajSkole(c, testneSkole())
+			if s.greska {
+				if err == nil {
+					t.Fatal("očekivana greška")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Ukupno != 5 {
+				t.Errorf("ukupno = %d", resp.Ukupno)
+			}
+			if dobiveno := idevi(resp.Podaci.([]models.Skola)); dobiveno != s.ocek {
+				t.Errorf("podaci = %s, očekivano %s", dobiveno, s.ocek)
+			}
+			provjeriLink(t, "sljedeca", resp.Sljedeca, s.sljedeca)
+			provjeriLink(t, "prethodna", resp.Prethodna, s.prethodna)
+		})
+	}
+}
+
+func provjeriLink(t *testing.T, naziv string, link *string, upit string) {
+	t.Helper()
+	if upit == "" {
+		if link != nil {
+			t.Errorf("%s = %s, očekivano nil", naziv, *link)
+		}
+		return
+	}
+	if ocek := "/api/v1/srednje-skole?" + upit; link == nil || *link != ocek {
+		t.Errorf("%s = %v, očekivano %s", naziv, link, ocek)
+	}
+}
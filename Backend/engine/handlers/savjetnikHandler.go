@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/ddobren/eduformacije/models"
+	"github.com/ddobren/eduformacije/services"
+	"github.com/gin-gonic/gin"
+)
+
+const maxDuljinaPoruke = 1000
+
+// PostSavjetnikSesijaHandler - POST /api/v1/savjetnik/sesije
+// Stvara sesiju s početnim filterima; ako je poslana i prva poruka, odmah vraća preporuke.
+func PostSavjetnikSesijaHandler(c *gin.Context) {
+	var reqBody models.SavjetnikSesijaRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		log.Printf("Nevažeći JSON body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nevažeći JSON body: " + err.Error()})
+		return
+	}
+
+	poruka, ok := provjeriPoruku(c, reqBody.Poruka, true)
+	if !ok {
+		return
+	}
+
+	vlasnik := subIzTokena(c)
+	if vlasnik == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "token nema \"sub\" claim potreban za sesiju"})
+		return
+	}
+
+	sesija, err := services.NovaSesija(reqBody.Filter(), vlasnik)
+	if err != nil {
+		log.Printf("Greška pri stvaranju sesije: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "greška pri stvaranju sesije"})
+		return
+	}
+
+	var odgovor models.SavjetnikOdgovor
+	if poruka == "" {
+		odgovor = services.PozdravSavjetnika(sesija)
+	} else {
+		idx, ok := dohvatiSkoleIndex(c)
+		if !ok {
+			return
+		}
+		odgovor, err = services.PosaljiPoruku(c.Request.Context(), sesija, poruka, idx)
+		if err != nil {
+			status, greska := odgovorNaGreskuAI(err)
+			c.JSON(status, gin.H{"error": greska})
+			return
+		}
+	}
+
+	if err := services.SpremiSesiju(c.Request.Context(), sesija); err != nil {
+		log.Printf("Greška pri spremanju sesije: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "greška pri spremanju sesije"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, odgovor)
+}
+
+// PostSavjetnikPorukaHandler - POST /api/v1/savjetnik/sesije/:id/poruke
+// Nova poruka učenika (npr. "nešto bliže Splitu", "bez prijemnog") i ažurirane preporuke
+func PostSavjetnikPorukaHandler(c *gin.Context) {
+	var reqBody models.SavjetnikPorukaRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		log.Printf("Nevažeći JSON body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nevažeći JSON body: " + err.Error()})
+		return
+	}
+
+	poruka, ok := provjeriPoruku(c, reqBody.Poruka, false)
+	if !ok {
+		return
+	}
+
+	sesija, ok := dohvatiSesiju(c)
+	if !ok {
+		return
+	}
+
+	idx, ok := dohvatiSkoleIndex(c)
+	if !ok {
+		return
+	}
+
+	odgovor, err := services.PosaljiPoruku(c.Request.Context(), sesija, poruka, idx)
+	if err != nil {
+		status, greska := odgovorNaGreskuAI(err)
+		c.JSON(status, gin.H{"error": greska})
+		return
+	}
+
+	if err := services.SpremiSesiju(c.Request.Context(), sesija); err != nil {
+		log.Printf("Greška pri spremanju sesije: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "greška pri spremanju sesije"})
+		return
+	}
+
+	c.JSON(http.StatusOK, odgovor)
+}
+
+// GetSavjetnikSesijaHandler - GET /api/v1/savjetnik/sesije/:id
+// Povijest razgovora, trenutni filteri i zadnje preporuke
+func GetSavjetnikSesijaHandler(c *gin.Context) {
+	sesija, ok := dohvatiSesiju(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, sesija)
+}
+
+// provjeriPoruku - propušta poruku kroz guard; prazna poruka je dozvoljena samo kod nove sesije
+func provjeriPoruku(c *gin.Context, poruka string, praznaDozvoljena bool) (string, bool) {
+	poruka, err := services.ProvjeriUnos(poruka, maxDuljinaPoruke)
+	if err != nil {
+		odbijUnos(c, err)
+		return "", false
+	}
+	if poruka == "" && !praznaDozvoljena {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "poruka je obavezna"})
+		return "", false
+	}
+	return poruka, true
+}
+
+// dohvatiSesiju - sesija iz :id parametra; 404 ako ne postoji, istekla je ili pripada drugom
+// tokenu (da se ne otkriva postoji li tuđa sesija)
+func dohvatiSesiju(c *gin.Context) (*models.SavjetnikSesija, bool) {
+	sesija, err := services.DohvatiSesiju(c.Request.Context(), c.Param("id"))
+	if err == nil && (sesija.Vlasnik == "" || sesija.Vlasnik != subIzTokena(c)) {
+		log.Printf("Pristup tuđoj sesiji savjetnika %s odbijen", sesija.Id)
+		err = services.ErrSesijaNePostoji
+	}
+	if errors.Is(err, services.ErrSesijaNePostoji) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Greška pri čitanju sesije: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "greška pri čitanju sesije"})
+		return nil, false
+	}
+	return sesija, true
+}
@@ -1,220 +1,341 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"log"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/ddobren/eduformacije/database"
 	"github.com/ddobren/eduformacije/models"
 	"github.com/ddobren/eduformacije/services"
 	"github.com/gin-gonic/gin"
 )
 
 // PostSugestijeHandler - POST /api/v1/srednje-skole/sugestije
+// Opcionalno: nacin (ai, offline) i expand=true
 func PostSugestijeHandler(c *gin.Context) {
+	zahtjev, ok := pripremiSugestije(c)
+	if !ok {
+		return
+	}
+
+	resp, err := services.PreporuciPrograme(
+		c.Request.Context(),
+		zahtjev.body.Interesi,
+		zahtjev.kandidati,
+		zahtjev.nacin,
+	)
+	if err != nil {
+		status, poruka := odgovorNaGreskuAI(err)
+		c.JSON(status, gin.H{"error": poruka})
+		return
+	}
+
+	zahtjev.prosiri(c, &resp)
+	c.JSON(http.StatusOK, resp)
+}
+
+// odbijUnos - odgovor na unos koji je odbio guard; razlog se logira bez samog teksta
+func odbijUnos(c *gin.Context, err error) {
+	var guardErr *services.GuardError
+	if !errors.As(err, &guardErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Guard: odbijen unos (razlog=%s, pravilo=%s, ip=%s, ruta=%s)",
+		guardErr.Razlog, guardErr.Detalj, c.ClientIP(), c.FullPath())
+	c.JSON(http.StatusBadRequest, gin.H{"error": guardErr.Poruka, "razlog": guardErr.Razlog})
+}
+
+// GetSugestijeCacheHandler - GET /api/v1/srednje-skole/sugestije/cache
+// Pogoci i promašaji cachea AI preporuka te broj spremljenih odgovora
+func GetSugestijeCacheHandler(c *gin.Context) {
+	stat, err := services.GetSugestijeCacheStatistika(c.Request.Context())
+	if err != nil {
+		log.Printf("Greška pri čitanju statistike cachea: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "greška pri čitanju statistike cachea"})
+		return
+	}
+	c.JSON(http.StatusOK, stat)
+}
+
+// sugestijeZahtjev - provjereni zahtjev za preporuke s kandidatima odabranim na serveru
+type sugestijeZahtjev struct {
+	body      models.SugestijeRequest
+	idx       *services.SkoleIndex
+	kandidati []models.Skola
+	nacin     string
+}
+
+// pripremiSugestije - parsira body i query te bira kandidate; kod greške zapisuje odgovor
+func pripremiSugestije(c *gin.Context) (*sugestijeZahtjev, bool) {
 	var reqBody models.SugestijeRequest
 	if err := c.ShouldBindJSON(&reqBody); err != nil {
 		log.Printf("Nevažeći JSON body: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Nevažeći JSON body: " + err.Error(),
 		})
-		return
+		return nil, false
 	}
 
-	explanation, recommendedPrograms, err := services.GetRecommendations(
-		reqBody.Interesi,
-		reqBody.Programi,
-	)
+	interesi, err := services.ProvjeriInteresi(reqBody.Interesi)
 	if err != nil {
-		log.Printf("Greška pri pozivu Gemini API: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "greška pri pozivu Gemini API: " + err.Error(),
-		})
-		return
+		odbijUnos(c, err)
+		return nil, false
 	}
+	if interesi == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interesi su obavezni"})
+		return nil, false
+	}
+	reqBody.Interesi = interesi
 
-	// Sastavimo odgovor
-	resp := models.SugestijeResponse{
-		Objasnjenje: explanation,
-		Programi:    recommendedPrograms,
+	nacin := c.Query("nacin")
+	if nacin != "" && nacin != services.NacinAI && nacin != services.NacinOffline {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nacin mora biti 'ai' ili 'offline'"})
+		return nil, false
 	}
 
-	c.JSON(http.StatusOK, resp)
+	idx, ok := dohvatiSkoleIndex(c)
+	if !ok {
+		return nil, false
+	}
+
+	kandidati := idx.Filtriraj(reqBody.Filter())
+	if len(kandidati) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "nema programa koji odgovaraju zadanim filterima"})
+		return nil, false
+	}
+
+	return &sugestijeZahtjev{body: reqBody, idx: idx, kandidati: kandidati, nacin: nacin}, true
 }
 
-// ------------------------------------------------------------
-func GetSrednjeSkoleHandler(c *gin.Context) {
-	rdb := database.GetRedisClient()
-	data, err := rdb.Get(c.Request.Context(), "skole_json").Result()
+// prosiri - uz ?expand=true odmah dodaje i pune zapise preporučenih programa
+func (z *sugestijeZahtjev) prosiri(c *gin.Context, resp *models.SugestijeResponse) {
+	if expand, _ := strconv.ParseBool(c.Query("expand")); !expand {
+		return
+	}
+	var ids []int
+	for _, p := range resp.Programi {
+		if id, err := strconv.Atoi(p.SkolaProgramRokId); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	resp.Detalji = z.idx.ProgramiPoId(ids)
+}
+
+// odgovorNaGreskuAI - HTTP status i poruka za grešku pri dohvaćanju preporuka
+func odgovorNaGreskuAI(err error) (int, string) {
+	var aiErr *services.AIOdgovorError
+	if errors.As(err, &aiErr) {
+		log.Printf("AI nije vratio valjan odgovor: %v", err)
+		return http.StatusBadGateway, "AI trenutno nije vratio valjan odgovor, pokušaj ponovno"
+	}
+	// Detalji greške (npr. odgovor providera) idu samo u log
+	log.Printf("Greška pri pozivu AI servisa: %v", err)
+	switch {
+	case errors.Is(err, services.ErrAINedostupan):
+		return http.StatusServiceUnavailable, "AI preporuke su trenutno nedostupne, pokušaj ponovno za koju minutu"
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "AI nije odgovorio na vrijeme, pokušaj ponovno"
+	default:
+		return http.StatusBadGateway, "greška pri pozivu AI servisa, pokušaj ponovno"
+	}
+}
+
+// dohvatiSkoleIndex - vraća aktivni index škola ili zapisuje grešku u odgovor
+func dohvatiSkoleIndex(c *gin.Context) (*services.SkoleIndex, bool) {
+	idx, err := services.GetSkoleIndex(c.Request.Context())
 	if err != nil {
-		log.Printf("Greška prilikom čitanja iz Redisa: %v", err)
+		log.Printf("Greška pri dohvaćanju indexa škola: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "greška prilikom čitanja podataka iz Redisa",
 		})
-		return
+		return nil, false
 	}
+	return idx, true
+}
 
-	var skole []models.Skola
-	if err := json.Unmarshal([]byte(data), &skole); err != nil {
-		log.Printf("Greška pri parsiranju JSON-a: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "greška pri parsiranju podataka",
-		})
-		return
+// parseSkoleFilter - čita zajedničke query filtere; kod nevažeće vrijednosti zapisuje 400 u odgovor
+func parseSkoleFilter(c *gin.Context) (models.SkoleFilter, bool) {
+	filter := models.SkoleFilter{
+		Zupanija:      strings.TrimSpace(c.Query("zupanija")),
+		Mjesto:        strings.TrimSpace(c.Query("mjesto")),
+		VrstaOsnivaca: strings.TrimSpace(c.Query("founderType")),
+		VrstaPrograma: strings.TrimSpace(c.Query("vrstaPrograma")),
 	}
 
-	filterZupanija := c.Query("zupanija")
-	filterMjesto := c.Query("mjesto")
-	filterFounderType := c.Query("founderType")
 	filterImaDodatnuProvjeru := c.Query("imaDodatnuProvjeru")
-
-	var filterProvjeraPtr *bool
 	if filterImaDodatnuProvjeru != "" {
 		val, errBool := strconv.ParseBool(filterImaDodatnuProvjeru)
 		if errBool == nil {
-			filterProvjeraPtr = &val
+			filter.ImaDodatnuProvjeru = &val
 		} else {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "nevažeća vrijednost za imaDodatnuProvjeru",
 			})
-			return
+			return filter, false
 		}
 	}
 
-	var filtered []models.Skola
-	for _, s := range skole {
-		sZupanija := strings.TrimSpace(s.Zupanija)
-		sMjesto := strings.TrimSpace(s.Mjesto)
-		sFounderType := strings.TrimSpace(s.VrstaOsnivaca)
+	return filter, true
+}
 
-		if filterZupanija != "" && !strings.EqualFold(sZupanija, filterZupanija) {
-			continue
-		}
-		if filterMjesto != "" && !strings.EqualFold(sMjesto, filterMjesto) {
-			continue
-		}
-		if filterFounderType != "" && !strings.EqualFold(sFounderType, filterFounderType) {
-			continue
-		}
-		if filterProvjeraPtr != nil {
-			if s.ImaDodatnuProvjeru == nil || *s.ImaDodatnuProvjeru != *filterProvjeraPtr {
-				continue
-			}
-		}
-		filtered = append(filtered, s)
+// ------------------------------------------------------------
+
+// GetSrednjeSkoleHandler - GET /api/v1/srednje-skole
+// Opcionalno: page, pageSize, sort (npr. "Kvota,-Prag") i fields (npr. "Skola,Program")
+func GetSrednjeSkoleHandler(c *gin.Context) {
+	idx, ok := dohvatiSkoleIndex(c)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseSkoleFilter(c)
+	if !ok {
+		return
 	}
 
-	c.JSON(http.StatusOK, filtered)
+	filtered := idx.Filtriraj(filter)
+
+	// Bez parametara paginacije zadržavamo stari format (cijela lista)
+	if !paginacijaZatrazena(c) {
+		c.JSON(http.StatusOK, filtered)
+		return
+	}
+
+	resp, err := paginirajSkole(c, filtered)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
 
 // GetZupanijeHandler - GET /v1/srednje-skole/zupanije
 func GetZupanijeHandler(c *gin.Context) {
-	rdb := database.GetRedisClient()
-	data, err := rdb.Get(c.Request.Context(), "skole_json").Result()
-	if err != nil {
-		log.Printf("Greška prilikom čitanja iz Redisa: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "greška prilikom čitanja podataka iz Redisa",
-		})
+	idx, ok := dohvatiSkoleIndex(c)
+	if !ok {
 		return
 	}
 
-	var skole []models.Skola
-	if err := json.Unmarshal([]byte(data), &skole); err != nil {
-		log.Printf("Greška pri parsiranju JSON-a: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "greška pri parsiranju podataka",
-		})
+	c.JSON(http.StatusOK, idx.Zupanije)
+}
+
+// GetMjestaHandler - GET /v1/srednje-skole/mjesta
+func GetMjestaHandler(c *gin.Context) {
+	idx, ok := dohvatiSkoleIndex(c)
+	if !ok {
 		return
 	}
 
-	distinctSet := make(map[string]bool)
-	for _, s := range skole {
-		z := strings.TrimSpace(s.Zupanija)
-		distinctSet[z] = true
-	}
+	c.JSON(http.StatusOK, idx.Mjesta)
+}
 
-	var result []string
-	for z := range distinctSet {
-		result = append(result, z)
+// GetVrsteOsnivacaHandler - GET /v1/srednje-skole/vrste-osnivaca
+func GetVrsteOsnivacaHandler(c *gin.Context) {
+	idx, ok := dohvatiSkoleIndex(c)
+	if !ok {
+		return
 	}
-	sort.Strings(result)
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, idx.VrsteOsnivaca)
 }
 
-// GetMjestaHandler - GET /v1/srednje-skole/mjesta
-func GetMjestaHandler(c *gin.Context) {
-	rdb := database.GetRedisClient()
-	data, err := rdb.Get(c.Request.Context(), "skole_json").Result()
-	if err != nil {
-		log.Printf("Greška prilikom čitanja iz Redisa: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "greška prilikom čitanja podataka iz Redisa",
-		})
+// GetSkoleGrupiranoHandler - GET /api/v1/srednje-skole/skole
+// Škole s ugniježđenim programima; podržava iste filtere kao /srednje-skole
+func GetSkoleGrupiranoHandler(c *gin.Context) {
+	filter, ok := parseSkoleFilter(c)
+	if !ok {
 		return
 	}
 
-	var skole []models.Skola
-	if err := json.Unmarshal([]byte(data), &skole); err != nil {
-		log.Printf("Greška pri parsiranju JSON-a: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "greška pri parsiranju podataka",
-		})
+	idx, ok := dohvatiSkoleIndex(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, idx.SkoleGrupirano(filter))
+}
+
+// GetSkolaHandler - GET /api/v1/srednje-skole/skole/:id
+func GetSkolaHandler(c *gin.Context) {
+	skolaId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nevažeći ID škole"})
 		return
 	}
 
-	distinctSet := make(map[string]bool)
-	for _, s := range skole {
-		m := strings.TrimSpace(s.Mjesto)
-		distinctSet[m] = true
+	idx, ok := dohvatiSkoleIndex(c)
+	if !ok {
+		return
 	}
 
-	var result []string
-	for m := range distinctSet {
-		result = append(result, m)
+	skola, found := idx.SkolaPoId(skolaId)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "škola nije pronađena"})
+		return
 	}
-	sort.Strings(result)
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, skola)
 }
 
-// GetVrsteOsnivacaHandler - GET /v1/srednje-skole/vrste-osnivaca
-func GetVrsteOsnivacaHandler(c *gin.Context) {
-	rdb := database.GetRedisClient()
-	data, err := rdb.Get(c.Request.Context(), "skole_json").Result()
+const maxProgramaPoZahtjevu = 100
+
+// GetProgramHandler - GET /api/v1/srednje-skole/programi/:skolaProgramRokId
+func GetProgramHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("skolaProgramRokId"))
 	if err != nil {
-		log.Printf("Greška prilikom čitanja iz Redisa: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "greška prilikom čitanja podataka iz Redisa",
-		})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nevažeći skolaProgramRokId"})
 		return
 	}
 
-	var skole []models.Skola
-	if err := json.Unmarshal([]byte(data), &skole); err != nil {
-		log.Printf("Greška pri parsiranju JSON-a: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "greška pri parsiranju podataka",
-		})
+	idx, ok := dohvatiSkoleIndex(c)
+	if !ok {
+		return
+	}
+
+	program, found := idx.ProgramPoId(id)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "program nije pronađen"})
 		return
 	}
 
-	distinctSet := make(map[string]bool)
-	for _, s := range skole {
-		v := strings.TrimSpace(s.VrstaOsnivaca)
-		distinctSet[v] = true
+	c.JSON(http.StatusOK, program)
+}
+
+// GetProgramiHandler - GET /api/v1/srednje-skole/programi?ids=1,2,3
+// Vraća pronađene programe redom kojim su zadani
+func GetProgramiHandler(c *gin.Context) {
+	var ids []int
+	for _, dio := range strings.Split(c.Query("ids"), ",") {
+		dio = strings.TrimSpace(dio)
+		if dio == "" {
+			continue
+		}
+		id, err := strconv.Atoi(dio)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "nevažeći ID: " + dio})
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametar ids je obavezan"})
+		return
+	}
+	if len(ids) > maxProgramaPoZahtjevu {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "previše ID-eva u jednom zahtjevu"})
+		return
 	}
 
-	var result []string
-	for v := range distinctSet {
-		result = append(result, v)
+	idx, ok := dohvatiSkoleIndex(c)
+	if !ok {
+		return
 	}
-	sort.Strings(result)
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, idx.ProgramiPoId(ids))
 }
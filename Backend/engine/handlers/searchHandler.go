@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ddobren/eduformacije/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetPretragaHandler - GET /api/v1/pretraga?q=
+// Opcionalno: izvor (programi, srednje, osnovne - odvojeno zarezom) i limit (default 20, max 100)
+func GetPretragaHandler(c *gin.Context) {
+	upit := strings.TrimSpace(c.Query("q"))
+	if upit == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametar q je obavezan"})
+		return
+	}
+
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil || v < 1 || v > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit mora biti između 1 i 100"})
+			return
+		}
+		limit = v
+	}
+
+	var izvori []string
+	if i := c.Query("izvor"); i != "" {
+		for _, izvor := range strings.Split(i, ",") {
+			izvor = strings.TrimSpace(izvor)
+			switch izvor {
+			case services.IzvorProgrami, services.IzvorSrednje, services.IzvorOsnovne:
+				izvori = append(izvori, izvor)
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "nepoznat izvor: " + izvor})
+				return
+			}
+		}
+	}
+
+	rezultati, err := services.Pretrazi(c.Request.Context(), upit, izvori, limit)
+	if err != nil {
+		log.Printf("Greška pri pretrazi: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "greška pri pretrazi"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rezultati)
+}
@@ -23,7 +23,7 @@ type LogEntry struct {
 	UserAgent  string `json:"user_agent"`
 }
 
-// GetStatusHandler - GET /api/v1/status
+// GetStatusHandler - GET /api/v1/admin/status (i zastarjeli GET /api/v1/status)
 func GetStatusHandler(c *gin.Context) {
 	file, err := os.Open("server.log")
 	if err != nil {
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"github.com/ddobren/eduformacije/services"
+	"github.com/gin-gonic/gin"
+)
+
+// PostSugestijeStreamHandler - POST /api/v1/srednje-skole/sugestije/stream
+// Isti body i parametri kao /sugestije, ali se odgovor šalje kao Server-Sent Events:
+// "objasnjenje" (dijelovi teksta), "program" (provjereni programi), "kraj" (cijeli odgovor) ili "greska".
+func PostSugestijeStreamHandler(c *gin.Context) {
+	zahtjev, ok := pripremiSugestije(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	emit := func(dogadjaj string, podaci interface{}) {
+		c.SSEvent(dogadjaj, podaci)
+		c.Writer.Flush()
+	}
+
+	resp, err := services.StreamPreporuke(
+		c.Request.Context(),
+		zahtjev.body.Interesi,
+		zahtjev.kandidati,
+		zahtjev.nacin,
+		emit,
+	)
+	if err != nil {
+		_, poruka := odgovorNaGreskuAI(err)
+		emit(services.DogadjajGreska, gin.H{"error": poruka})
+		return
+	}
+
+	zahtjev.prosiri(c, &resp)
+	emit(services.DogadjajKraj, resp)
+}
@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/ddobren/eduformacije/config"
@@ -20,14 +24,19 @@ import (
 var logger = logrus.New()
 
 func init() {
-	logger.SetOutput(&lumberjack.Logger{
+	logger.SetOutput(config.RedaktirajuciWriter(&lumberjack.Logger{
 		Filename:   "./server.log",
 		MaxSize:    1024,
 		MaxBackups: 3,
 		MaxAge:     28,
 		Compress:   true,
-	})
+	}))
 	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	// API ključevi i lozinke ne smiju završiti ni u jednom logu
+	log.SetOutput(config.RedaktirajuciWriter(os.Stderr))
+	gin.DefaultWriter = config.RedaktirajuciWriter(os.Stdout)
+	gin.DefaultErrorWriter = config.RedaktirajuciWriter(os.Stderr)
 }
 
 func logRequestDetails(c *gin.Context) {
@@ -53,19 +62,58 @@ func logError(err error) {
 	}).Error("An error occurred")
 }
 
+// ponovnoUcitajNaSIGHUP - rotacija tajni bez restarta (kill -HUP <pid>), isto što i
+// POST /api/v1/admin/tajne/ponovno-ucitaj. Ako nova konfiguracija nije ispravna, ostaje stara.
+func ponovnoUcitajNaSIGHUP() {
+	signali := make(chan os.Signal, 1)
+	signal.Notify(signali, syscall.SIGHUP)
+	for range signali {
+		log.Println("SIGHUP: ponovno učitavanje tajni, API klijenata i JWT ključeva")
+		services.PonovnoUcitajTajne()
+	}
+}
+
 func startPolling(interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	for {
 		<-ticker.C
-		if err := services.UpdateSkoleData(); err != nil {
+		if _, err := services.IzvrsiIngest("programi", "raspored"); err != nil {
 			logError(err)
 		}
 	}
 }
 
+// limitIzKonfiguracije - broj zahtjeva u sekundi iz env varijable
+func limitIzKonfiguracije(kljuc string, zadano int) int {
+	limit, err := strconv.Atoi(config.GetEnv(kljuc, strconv.Itoa(zadano)))
+	if err != nil || limit <= 0 {
+		return zadano
+	}
+	return limit
+}
+
 func main() {
 	config.InitConfig()
 
+	// Predlošci prompta se provjeravaju odmah, a ne tek kod prvog AI zahtjeva
+	if err := services.UcitajPrompte(); err != nil {
+		log.Fatalf("❌ Greška pri učitavanju prompta: %v", err)
+	}
+
+	// API_SECRET potpisuje izazove anonimnih sesija; prazan ili kratak bi ih učinio krivotvorivima
+	if err := services.ProvjeriTajnuIzazova(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	if err := services.UcitajKlijente(); err != nil {
+		log.Fatalf("❌ Greška pri učitavanju API klijenata: %v", err)
+	}
+
+	if err := services.UcitajJWTKljuceve(); err != nil {
+		log.Fatalf("❌ Greška pri učitavanju JWT ključeva: %v", err)
+	}
+	go ponovnoUcitajNaSIGHUP()
+
 	database.InitRedis()
 	rdb := database.GetRedisClient()
 
@@ -74,7 +122,7 @@ func main() {
 
 	// Pokreni UpdateSkoleData u goroutine
 	go func() {
-		if err := services.UpdateSkoleData(); err != nil {
+		if _, err := services.IzvrsiIngest("programi", "pokretanje"); err != nil {
 			log.Printf("❌ Greška pri ažuriranju škola u Redis: %v", err)
 		}
 		redisDone <- true
@@ -89,8 +137,9 @@ func main() {
 	}
 
 	database.InitMongo()
-	services.UpdateSrednjeSkole()
-	services.UpdateOsnovneSkole()
+	services.InitAPIKljuceve()
+	services.IzvrsiIngest("srednje", "pokretanje")
+	services.IzvrsiIngest("osnovne", "pokretanje")
 
 	ginMode := config.GetEnv("GIN_MODE", "debug")
 	gin.SetMode(ginMode)
@@ -105,30 +154,89 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "X-AI-Kvota-Preostalo"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	r.Use(handlers.CustomRateLimiter(rdb, 10, time.Second))
+	// Limit po IP-u je namjerno blaži jer iza istog NAT-a može biti cijela škola;
+	// pravi limit je po tokenu (sesiji ili klijentu), vidi SesijaRateLimiter
+	r.Use(handlers.CustomRateLimiter(rdb, limitIzKonfiguracije("RATE_LIMIT_IP", 30), time.Second))
+	r.Use(handlers.OdrzavanjeMiddleware())
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
 
-	api := r.Group("/api/v1", handlers.JWTAuthMiddleware())
+	// Izdavanje tokena i anonimne sesije su jedine rute bez JWT-a
+	auth := r.Group("/api/v1/auth")
 	{
-		api.POST("/srednje-skole/sugestije", handlers.PostSugestijeHandler)
-		api.GET("/srednje-skole", handlers.GetSrednjeSkoleHandler)
-		api.GET("/srednje-skole/zupanije", handlers.GetZupanijeHandler)
-		api.GET("/srednje-skole/mjesta", handlers.GetMjestaHandler)
-		api.GET("/srednje-skole/vrste-osnivaca", handlers.GetVrsteOsnivacaHandler)
+		auth.POST("/token", handlers.PostTokenHandler)
+		auth.GET("/izazov", handlers.GetIzazovHandler)
+		auth.POST("/sesija", handlers.PostAnonimnaSesijaHandler)
+	}
+
+	api := r.Group("/api/v1",
+		handlers.JWTAuthMiddleware(),
+		handlers.SesijaRateLimiter(rdb, limitIzKonfiguracije("RATE_LIMIT_SESIJA", 10), time.Second),
+	)
+	{
+		ai := api.Group("", handlers.ZahtijevajScope(services.ScopeAISugestije))
+		{
+			ai.POST("/srednje-skole/sugestije", handlers.AIKvotaMiddleware(true), handlers.PostSugestijeHandler) // ?expand=true vraća i pune zapise
+			ai.POST("/srednje-skole/sugestije/stream", handlers.AIKvotaMiddleware(true), handlers.PostSugestijeStreamHandler)
+			ai.GET("/srednje-skole/sugestije/cache", handlers.GetSugestijeCacheHandler)
+
+			ai.POST("/savjetnik/sesije", handlers.AIKvotaMiddleware(false), handlers.PostSavjetnikSesijaHandler)
+			ai.GET("/savjetnik/sesije/:id", handlers.GetSavjetnikSesijaHandler)
+			ai.POST("/savjetnik/sesije/:id/poruke", handlers.AIKvotaMiddleware(false), handlers.PostSavjetnikPorukaHandler)
+		}
 
-		api.GET("/skole/srednje", handlers.GetSrednjeHandler)
-		api.GET("/skole/osnovne", handlers.GetOsnovneHandler)
+		skole := api.Group("", handlers.ZahtijevajScope(services.ScopeCitanjeSkola))
+		{
+			skole.GET("/srednje-skole", handlers.GetSrednjeSkoleHandler)
+			skole.GET("/srednje-skole/zupanije", handlers.GetZupanijeHandler)
+			skole.GET("/srednje-skole/mjesta", handlers.GetMjestaHandler)
+			skole.GET("/srednje-skole/vrste-osnivaca", handlers.GetVrsteOsnivacaHandler)
+			skole.GET("/srednje-skole/blizu", handlers.GetSkoleBlizuHandler)
+			skole.GET("/srednje-skole/okvir", handlers.GetSkoleUOkviruHandler)
+			skole.GET("/srednje-skole/skole", handlers.GetSkoleGrupiranoHandler)
+			skole.GET("/srednje-skole/skole/:id", handlers.GetSkolaHandler)
+			skole.GET("/srednje-skole/programi", handlers.GetProgramiHandler)
+			skole.GET("/srednje-skole/programi/:skolaProgramRokId", handlers.GetProgramHandler)
+
+			skole.GET("/skole/srednje", handlers.GetSrednjeHandler)
+			skole.GET("/skole/osnovne", handlers.GetOsnovneHandler)
+
+			skole.GET("/pretraga", handlers.GetPretragaHandler)
+		}
+
+		// Zastarjelo: /status je premješten u /admin/status jer vraća zapise zahtjeva s IP adresama
+		api.GET("/status", handlers.Zastarjelo("/api/v1/admin/status"), handlers.ZahtijevajUlogu("admin"), handlers.GetStatusHandler)
+
+		// Audit je ispred provjere uloge da se bilježe i odbijeni pokušaji
+		admin := api.Group("/admin", handlers.AuditMiddleware(), handlers.ZahtijevajUlogu("admin"))
+		{
+			admin.GET("/status", handlers.GetStatusHandler)
+			admin.GET("/audit", handlers.GetAuditHandler) // ?limit=100
+
+			admin.GET("/ingest", handlers.GetIngestHandler)
+			admin.POST("/ingest/:posao", handlers.PostIngestHandler)     // programi, srednje, osnovne
+			admin.POST("/cache/ocisti", handlers.PostOcistiCacheHandler) // ?vrsta=sve
+			admin.POST("/tajne/ponovno-ucitaj", handlers.PostPonovnoUcitajTajneHandler)
+			admin.GET("/odrzavanje", handlers.GetOdrzavanjeHandler)
+			admin.PUT("/odrzavanje", handlers.PutOdrzavanjeHandler)
 
-		api.GET("/status", handlers.GetStatusHandler)
+			admin.GET("/ai/potrosnja", handlers.GetAIPotrosnjaHandler) // ?dana=7
+
+			admin.POST("/api-kljucevi", handlers.PostAPIKljucHandler)
+			admin.GET("/api-kljucevi", handlers.GetAPIKljuceviHandler)
+			admin.DELETE("/api-kljucevi/:id", handlers.DeleteAPIKljucHandler)
+			admin.GET("/api-kljucevi/:id/koristenje", handlers.GetAPIKljucKoristenjeHandler) // ?dana=30
+		}
 	}
 
+	r.GET("/.well-known/jwks.json", handlers.GetJWKSHandler)
+
 	r.GET("/favicon.ico", func(c *gin.Context) {
 		c.AbortWithStatus(http.StatusNoContent)
 	})
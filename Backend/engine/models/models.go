@@ -2,6 +2,11 @@
 
 package models
 
+import (
+	"strings"
+	"time"
+)
+
 type Skola struct {
 	Skola              string   `json:"Skola"`
 	EMail              string   `json:"EMail"`
@@ -28,21 +33,232 @@ type Skola struct {
 	ImaDodatnuProvjeru *bool    `json:"ImaDodatnuProvjeru"`
 }
 
-// Struktura za (skolaProgramRokId, program) - kako stiže iz frontenda i vraća se natrag
+// SkoleFilter - filteri nad programima srednjih škola (prazno polje = bez filtera)
+type SkoleFilter struct {
+	Zupanija           string `json:"zupanija,omitempty"`
+	Mjesto             string `json:"mjesto,omitempty"`
+	VrstaOsnivaca      string `json:"vrstaOsnivaca,omitempty"`
+	VrstaPrograma      string `json:"vrstaPrograma,omitempty"`
+	ImaDodatnuProvjeru *bool  `json:"imaDodatnuProvjeru,omitempty"`
+}
+
 // ProgramWithID - jedan program s pridruženim ID-om škole
 type ProgramWithID struct {
-	SkolaProgramRokId string `json:"skolaProgramRokId"`
-	Program           string `json:"program"`
+	SkolaProgramRokId string   `json:"skolaProgramRokId"`
+	Program           string   `json:"program"`
+	Razlog            string   `json:"razlog,omitempty"` // zašto program odgovara interesima
+	Podudaranje       float64  `json:"podudaranje"`      // 0-1, koliko program odgovara interesima
+	Oznake            []string `json:"oznake,omitempty"` // iz skupa podataka, npr. "prijemni ispit", "4 godine"
+}
+
+// TokenRequest - POST /api/v1/auth/token (client credentials; JSON ili form, polja kao u OAuth2).
+// Klijent se može predstaviti i kroz HTTP Basic autentikaciju.
+type TokenRequest struct {
+	GrantType    string `json:"grant_type" form:"grant_type"`
+	ClientId     string `json:"client_id" form:"client_id"`
+	ClientSecret string `json:"client_secret" form:"client_secret"`
+	Scope        string `json:"scope" form:"scope"` // scope-ovi odvojeni razmakom; prazno = svi dozvoljeni
+}
+
+// TokenResponse - izdani pristupni token
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"` // sekunde
+	Scope       string `json:"scope"`
+}
+
+// AnonimnaSesijaRequest - POST /api/v1/auth/sesija: izazov iz GET /auth/izazov i pronađeno rješenje
+type AnonimnaSesijaRequest struct {
+	Izazov   string `json:"izazov" binding:"required"`
+	Rjesenje string `json:"rjesenje" binding:"required"`
+}
+
+// APIKljuc - ključ vanjskog integratora (kolekcija apiKljucevi). Sam ključ se ne čuva, samo njegov SHA-256 hash.
+type APIKljuc struct {
+	Id                string     `bson:"_id" json:"id"`
+	Naziv             string     `bson:"naziv" json:"naziv"`
+	Prefiks           string     `bson:"prefiks" json:"prefiks"` // početak ključa, da ga integrator prepozna
+	Hash              string     `bson:"hash" json:"-"`
+	Scope             []string   `bson:"scope" json:"scope"`
+	LimitPoSekundi    int        `bson:"limitPoSekundi" json:"limitPoSekundi"`
+	DnevniLimit       int        `bson:"dnevniLimit" json:"dnevniLimit"`             // 0 = bez dnevnog limita
+	DozvoljeniOrigini []string   `bson:"dozvoljeniOrigini" json:"dozvoljeniOrigini"` // prazno = svi
+	DozvoljeneRute    []string   `bson:"dozvoljeneRute" json:"dozvoljeneRute"`       // npr. /api/v1/srednje-skole/*; prazno = sve
+	Kreiran           time.Time  `bson:"kreiran" json:"kreiran"`
+	Opozvan           *time.Time `bson:"opozvan,omitempty" json:"opozvan,omitempty"`
+	KoristenoDanas    int64      `bson:"-" json:"koristenoDanas"`
+}
+
+// APIKljucRequest - POST /api/v1/admin/api-kljucevi
+type APIKljucRequest struct {
+	Naziv             string   `json:"naziv" binding:"required"`
+	Scope             []string `json:"scope"` // prazno = read:skole
+	LimitPoSekundi    int      `json:"limitPoSekundi"`
+	DnevniLimit       int      `json:"dnevniLimit"`
+	DozvoljeniOrigini []string `json:"dozvoljeniOrigini"`
+	DozvoljeneRute    []string `json:"dozvoljeneRute"`
+}
+
+// NoviAPIKljucResponse - ključ se prikazuje samo jednom, pri stvaranju
+type NoviAPIKljucResponse struct {
+	Kljuc    string   `json:"kljuc"`
+	APIKljuc APIKljuc `json:"apiKljuc"`
 }
 
-// SugestijeRequest - JSON koji stiže od frontenda
+// OdrzavanjeRequest - PUT /api/v1/admin/odrzavanje
+type OdrzavanjeRequest struct {
+	Ukljuceno *bool  `json:"ukljuceno" binding:"required"`
+	Poruka    string `json:"poruka"` // prikazuje se korisnicima umjesto zadane poruke
+}
+
+// SugestijeRequest - JSON koji stiže od frontenda.
+// Kandidate za prijedloge server bira sam iz skupa podataka, prema istim filterima kao GET /srednje-skole.
 type SugestijeRequest struct {
-	Interesi string          `json:"interesi"`
-	Programi []ProgramWithID `json:"programi"`
+	Interesi           string `json:"interesi"`
+	Zupanija           string `json:"zupanija"`
+	Mjesto             string `json:"mjesto"`
+	FounderType        string `json:"founderType"`
+	ImaDodatnuProvjeru *bool  `json:"imaDodatnuProvjeru"`
+}
+
+// Filter - filteri iz zahtjeva u obliku za index škola
+func (r SugestijeRequest) Filter() SkoleFilter {
+	return SkoleFilter{
+		Zupanija:           strings.TrimSpace(r.Zupanija),
+		Mjesto:             strings.TrimSpace(r.Mjesto),
+		VrstaOsnivaca:      strings.TrimSpace(r.FounderType),
+		ImaDodatnuProvjeru: r.ImaDodatnuProvjeru,
+	}
 }
 
 // SugestijeResponse - JSON koji vraćamo frontendu
 type SugestijeResponse struct {
 	Objasnjenje string          `json:"objasnjenje"`
 	Programi    []ProgramWithID `json:"programi"`
+	Detalji     []Skola         `json:"detalji,omitempty"` // samo uz ?expand=true
+	Meta        SugestijeMeta   `json:"meta"`
+}
+
+// SugestijeMeta - podaci o obradi AI odgovora
+type SugestijeMeta struct {
+	Ispravljeno int `json:"ispravljeno"` // popravljeni ID-evi ili nazivi programa
+	Odbaceno    int `json:"odbaceno"`    // programi koji ne postoje među kandidatima
+	Duplikati   int `json:"duplikati"`
+
+	Nacin   string `json:"nacin"`             // "ai" ili "offline"
+	Rezerva bool   `json:"rezerva,omitempty"` // true ako je AI zakazao pa je korišten offline način
+	Cache   bool   `json:"cache,omitempty"`   // true ako je odgovor vraćen iz cachea
+
+	Degradirano bool `json:"degraded,omitempty"` // true ako AI nije bio dostupan (otvoren circuit breaker, istek vremena, greška providera)
+
+	PromptVerzija string `json:"promptVerzija,omitempty"` // verzija predloška prompta (samo AI način)
+}
+
+// StranicaResponse - omotnica za paginirane odgovore
+type StranicaResponse struct {
+	Ukupno           int         `json:"ukupno"`
+	Stranica         int         `json:"stranica"`
+	VelicinaStranice int         `json:"velicinaStranice"`
+	Podaci           interface{} `json:"podaci"`
+	Sljedeca         *string     `json:"sljedeca"`
+	Prethodna        *string     `json:"prethodna"`
+}
+
+// PretragaRezultat - jedan pogodak pretrage (program iz e-upisa ili škola iz Mongo kolekcije)
+type PretragaRezultat struct {
+	Izvor        string      `json:"izvor"`
+	Naziv        string      `json:"naziv"`
+	Mjesto       string      `json:"mjesto"`
+	Adresa       string      `json:"adresa"`
+	Relevantnost float64     `json:"relevantnost"`
+	Podaci       interface{} `json:"podaci"`
+}
+
+// SkolaUdaljenost - program s udaljenošću od zadane točke
+type SkolaUdaljenost struct {
+	Skola
+	UdaljenostKm float64 `json:"UdaljenostKm"`
+}
+
+// SkolaProgram - jedan program unutar škole (bez ponovljenih podataka o školi)
+type SkolaProgram struct {
+	SkolaProgramRokId  int    `json:"SkolaProgramRokId"`
+	Program            string `json:"Program"`
+	VrstaPrograma      string `json:"VrstaPrograma"`
+	VrstaProgramaId    int    `json:"VrstaProgramaId"`
+	Kvota              int    `json:"Kvota"`
+	ParalelnaKvota     int    `json:"ParalelnaKvota"`
+	Trajanje           int    `json:"Trajanje"`
+	Prag               *int   `json:"Prag"`
+	ImaDodatnuProvjeru *bool  `json:"ImaDodatnuProvjeru"`
+}
+
+// SkolaDetalji - jedna škola s popisom svojih programa
+type SkolaDetalji struct {
+	SkolaId       int            `json:"SkolaId"`
+	Skola         string         `json:"Skola"`
+	Adresa        string         `json:"Adresa"`
+	Mjesto        string         `json:"Mjesto"`
+	Zupanija      string         `json:"Zupanija"`
+	Lat           *float64       `json:"Lat"`
+	Lng           *float64       `json:"Lng"`
+	EMail         string         `json:"EMail"`
+	BrojTelefona  string         `json:"BrojTelefona"`
+	BrojFaksa     *string        `json:"BrojFaksa"`
+	Web           *string        `json:"Web"`
+	VrstaOsnivaca string         `json:"VrstaOsnivaca"`
+	Programi      []SkolaProgram `json:"Programi"`
+}
+
+// SavjetnikPoruka - jedna poruka u razgovoru sa savjetnikom
+type SavjetnikPoruka struct {
+	Uloga   string    `json:"uloga"` // "ucenik" ili "savjetnik"
+	Tekst   string    `json:"tekst"`
+	Vrijeme time.Time `json:"vrijeme"`
+}
+
+// SavjetnikSesija - razgovor sa savjetnikom; čuva se u Redisu
+type SavjetnikSesija struct {
+	Id        string            `json:"id"`
+	Vlasnik   string            `json:"vlasnik"` // "sub" tokena koji je stvorio sesiju
+	Kreirana  time.Time         `json:"kreirana"`
+	Azurirana time.Time         `json:"azurirana"`
+	Filteri   SkoleFilter       `json:"filteri"`
+	Poruke    []SavjetnikPoruka `json:"poruke"`
+	Programi  []ProgramWithID   `json:"programi"` // zadnje preporuke
+}
+
+// SavjetnikSesijaRequest - POST /savjetnik/sesije; početni filteri i opcionalna prva poruka
+type SavjetnikSesijaRequest struct {
+	Poruka             string `json:"poruka"`
+	Zupanija           string `json:"zupanija"`
+	Mjesto             string `json:"mjesto"`
+	FounderType        string `json:"founderType"`
+	ImaDodatnuProvjeru *bool  `json:"imaDodatnuProvjeru"`
+}
+
+// Filter - početni filteri sesije u obliku za index škola
+func (r SavjetnikSesijaRequest) Filter() SkoleFilter {
+	return SkoleFilter{
+		Zupanija:           strings.TrimSpace(r.Zupanija),
+		Mjesto:             strings.TrimSpace(r.Mjesto),
+		VrstaOsnivaca:      strings.TrimSpace(r.FounderType),
+		ImaDodatnuProvjeru: r.ImaDodatnuProvjeru,
+	}
+}
+
+// SavjetnikPorukaRequest - POST /savjetnik/sesije/:id/poruke
+type SavjetnikPorukaRequest struct {
+	Poruka string `json:"poruka"`
+}
+
+// SavjetnikOdgovor - odgovor savjetnika s ažuriranim preporukama
+type SavjetnikOdgovor struct {
+	SesijaId string          `json:"sesijaId"`
+	Odgovor  string          `json:"odgovor"`
+	Pitanje  string          `json:"pitanje,omitempty"` // dodatno pitanje za suženje izbora
+	Programi []ProgramWithID `json:"programi"`
+	Filteri  SkoleFilter     `json:"filteri"`
+	Meta     SugestijeMeta   `json:"meta"`
 }
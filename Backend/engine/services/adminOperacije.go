@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ddobren/eduformacije/config"
+	"github.com/ddobren/eduformacije/database"
+	"github.com/redis/go-redis/v9"
+)
+
+// Operacije koje su se do sada radile samo pri pokretanju ili na 24h tickeru, a sada ih
+// može pokrenuti i administrator (/api/v1/admin).
+
+const ingestZadnjePrefix = "admin:ingest:zadnje:"
+
+var (
+	ErrNepoznatPosao        = errors.New("nepoznat ingest posao")
+	ErrPosaoVecRadi         = errors.New("ingest posao se već izvršava")
+	ErrNepoznataVrstaCachea = errors.New("nepoznata vrsta cachea")
+)
+
+// ingestPosao - jedan izvor podataka
+type ingestPosao struct {
+	opis    string
+	pokreni func() error
+}
+
+var ingestPoslovi = map[string]ingestPosao{
+	"programi": {"Programi srednjih škola (SREDNJE_SKOLE_INFO_URL) u Redis i index škola", UpdateSkoleData},
+	"srednje":  {"Srednje škole (SREDNJE_SKOLE_URL) u Mongo i index pretrage", UpdateSrednjeSkole},
+	"osnovne":  {"Osnovne škole (OSNOVNE_SKOLE_URL) u Mongo i index pretrage", UpdateOsnovneSkole},
+}
+
+// IngestRezultat - zadnje izvršavanje posla
+type IngestRezultat struct {
+	Posao      string    `json:"posao"`
+	Pokrenuo   string    `json:"pokrenuo"` // "pokretanje", "raspored" ili sub administratora
+	Pocetak    time.Time `json:"pocetak"`
+	Kraj       time.Time `json:"kraj,omitempty"`
+	TrajanjeMs int64     `json:"trajanjeMs"`
+	Uspjeh     bool      `json:"uspjeh"`
+	Greska     string    `json:"greska,omitempty"`
+}
+
+// IngestStatus - posao s opisom, zadnjim rezultatom i znakom izvršava li se upravo
+type IngestStatus struct {
+	Posao  string          `json:"posao"`
+	Opis   string          `json:"opis"`
+	Radi   bool            `json:"radi"`
+	Zadnje *IngestRezultat `json:"zadnje,omitempty"`
+}
+
+var (
+	ingestMu   sync.Mutex
+	ingestRadi = map[string]bool{}
+)
+
+// IzvrsiIngest - izvršava posao i sprema rezultat. Isti posao ne može raditi dvaput istovremeno.
+func IzvrsiIngest(posao, pokrenuo string) (IngestRezultat, error) {
+	p, ok := ingestPoslovi[posao]
+	if !ok {
+		return IngestRezultat{}, ErrNepoznatPosao
+	}
+
+	ingestMu.Lock()
+	if ingestRadi[posao] {
+		ingestMu.Unlock()
+		return IngestRezultat{}, ErrPosaoVecRadi
+	}
+	ingestRadi[posao] = true
+	ingestMu.Unlock()
+
+	defer func() {
+		ingestMu.Lock()
+		delete(ingestRadi, posao)
+		ingestMu.Unlock()
+	}()
+
+	rezultat := IngestRezultat{Posao: posao, Pokrenuo: pokrenuo, Pocetak: time.Now()}
+	log.Printf("Ingest %s pokrenut (%s)", posao, pokrenuo)
+	err := p.pokreni()
+	rezultat.Kraj = time.Now()
+	rezultat.TrajanjeMs = rezultat.Kraj.Sub(rezultat.Pocetak).Milliseconds()
+	rezultat.Uspjeh = err == nil
+	if err != nil {
+		rezultat.Greska = err.Error()
+		log.Printf("❌ Ingest %s nije uspio nakon %dms: %v", posao, rezultat.TrajanjeMs, err)
+	} else {
+		log.Printf("Ingest %s završen za %dms", posao, rezultat.TrajanjeMs)
+	}
+
+	spremiIngestRezultat(rezultat)
+	return rezultat, err
+}
+
+// PokreniIngest - kao IzvrsiIngest, ali u pozadini; greška se vraća samo ako posao nije pokrenut
+func PokreniIngest(posao, pokrenuo string) error {
+	if _, ok := ingestPoslovi[posao]; !ok {
+		return ErrNepoznatPosao
+	}
+	ingestMu.Lock()
+	radi := ingestRadi[posao]
+	ingestMu.Unlock()
+	if radi {
+		return ErrPosaoVecRadi
+	}
+
+	go IzvrsiIngest(posao, pokrenuo)
+	return nil
+}
+
+func spremiIngestRezultat(r IngestRezultat) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := database.GetRedisClient().Set(ctx, ingestZadnjePrefix+r.Posao, data, 0).Err(); err != nil {
+		log.Printf("Greška pri spremanju rezultata ingesta %s: %v", r.Posao, err)
+	}
+}
+
+// StatusIngesta - svi poslovi sa zadnjim rezultatom
+func StatusIngesta(ctx context.Context) ([]IngestStatus, error) {
+	rdb := database.GetRedisClient()
+
+	statusi := make([]IngestStatus, 0, len(ingestPoslovi))
+	for naziv, p := range ingestPoslovi {
+		ingestMu.Lock()
+		radi := ingestRadi[naziv]
+		ingestMu.Unlock()
+
+		s := IngestStatus{Posao: naziv, Opis: p.opis, Radi: radi}
+		data, err := rdb.Get(ctx, ingestZadnjePrefix+naziv).Bytes()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("greška pri čitanju rezultata ingesta: %w", err)
+		}
+		if err == nil {
+			var r IngestRezultat
+			if json.Unmarshal(data, &r) == nil {
+				s.Zadnje = &r
+			}
+		}
+		statusi = append(statusi, s)
+	}
+	sort.Slice(statusi, func(i, j int) bool { return statusi[i].Posao < statusi[j].Posao })
+	return statusi, nil
+}
+
+// OcistiCache - vrsta: sugestije (AI odgovori u Redisu), api-kljucevi (provjereni ključevi u
+// memoriji), indeksi (index škola i pretrage se ponovno grade pri sljedećem zahtjevu) ili sve.
+// Vraća broj obrisanih zapisa po vrsti.
+func OcistiCache(ctx context.Context, vrsta string) (map[string]int, error) {
+	obrisano := map[string]int{}
+	sve := vrsta == "sve"
+
+	if sve || vrsta == "sugestije" {
+		n, err := OcistiSugestijeCache(ctx)
+		if err != nil {
+			return obrisano, fmt.Errorf("greška pri brisanju cachea sugestija: %w", err)
+		}
+		obrisano["sugestije"] = n
+	}
+	if sve || vrsta == "api-kljucevi" {
+		apiKljucCache.mu.Lock()
+		obrisano["api-kljucevi"] = len(apiKljucCache.unosi)
+		apiKljucCache.mu.Unlock()
+		apiKljucCache.obrisiSve()
+	}
+	if sve || vrsta == "indeksi" {
+		skoleIndexMu.Lock()
+		if skoleIndex.Swap(nil) != nil {
+			obrisano["indeksi"]++
+		}
+		skoleIndexMu.Unlock()
+
+		pretragaMu.Lock()
+		obrisano["indeksi"] += len(pretragaMongo)
+		pretragaMongo = map[string][]pretragaDokument{}
+		pretragaMu.Unlock()
+	}
+
+	if len(obrisano) == 0 {
+		return nil, ErrNepoznataVrstaCachea
+	}
+	return obrisano, nil
+}
+
+// RezultatUcitavanja - ishod ponovnog učitavanja jednog dijela konfiguracije
+type RezultatUcitavanja struct {
+	Dio    string `json:"dio"`
+	Uspjeh bool   `json:"uspjeh"`
+	Greska string `json:"greska,omitempty"`
+}
+
+// ponovnoUcitajEnv - .env i provjera da je API_SECRET i dalje dovoljno dug
+func ponovnoUcitajEnv() error {
+	if err := config.PonovnoUcitajEnv(); err != nil {
+		return err
+	}
+	return ProvjeriTajnuIzazova()
+}
+
+// PonovnoUcitajTajne - rotacija bez restarta: .env (API ključevi modela, API_SECRET), registar
+// klijenata i JWT ključevi. Dio koji se ne može učitati zadržava staro stanje.
+func PonovnoUcitajTajne() []RezultatUcitavanja {
+	dijelovi := []struct {
+		naziv  string
+		ucitaj func() error
+	}{
+		{"env", ponovnoUcitajEnv},
+		{"klijenti", UcitajKlijente},
+		{"jwt-kljucevi", UcitajJWTKljuceve},
+	}
+
+	rezultati := make([]RezultatUcitavanja, 0, len(dijelovi))
+	for _, d := range dijelovi {
+		r := RezultatUcitavanja{Dio: d.naziv, Uspjeh: true}
+		if err := d.ucitaj(); err != nil {
+			r.Uspjeh = false
+			r.Greska = err.Error()
+			log.Printf("❌ Greška pri ponovnom učitavanju (%s): %v", d.naziv, err)
+		}
+		rezultati = append(rezultati, r)
+	}
+	return rezultati
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ddobren/eduformacije/config"
+	"github.com/ddobren/eduformacije/database"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	aiKvotaBucketPrefix = "ai:kvota:bucket:"
+	aiKvotaDnevnoPrefix = "ai:kvota:dnevno:"
+	aiPotrosnjaPrefix   = "ai:potrosnja:"
+	aiPotrosnjaTrajanje = 90 * 24 * time.Hour
+	aiTopKlijenata      = 10
+)
+
+// aiKvotaSkripta - token bucket i dnevni limit u jednom atomskom koraku.
+// KEYS: bucket, dnevni brojač; ARGV: kapacitet, tokena po ms, sada (ms), dnevni limit (0 = bez), trajanje bucketa (ms).
+// Vraća {dozvoljeno, čekanje u ms (-1 = do sutra), preostalo danas (-1 = bez limita)}.
+var aiKvotaSkripta = redis.NewScript(`
+local kapacitet = tonumber(ARGV[1])
+local brzina = tonumber(ARGV[2])
+local sada = tonumber(ARGV[3])
+local dnevniLimit = tonumber(ARGV[4])
+
+local iskoristeno = tonumber(redis.call('GET', KEYS[2]) or '0')
+if dnevniLimit > 0 and iskoristeno >= dnevniLimit then
+	return {0, -1, 0}
+end
+
+local stanje = redis.call('HMGET', KEYS[1], 'tokeni', 'ts')
+local tokeni = tonumber(stanje[1]) or kapacitet
+local ts = tonumber(stanje[2]) or sada
+tokeni = math.min(kapacitet, tokeni + math.max(0, sada - ts) * brzina)
+
+local preostalo = -1
+if tokeni < 1 then
+	redis.call('HSET', KEYS[1], 'tokeni', tostring(tokeni), 'ts', sada)
+	redis.call('PEXPIRE', KEYS[1], ARGV[5])
+	if dnevniLimit > 0 then preostalo = dnevniLimit - iskoristeno end
+	return {0, math.ceil((1 - tokeni) / brzina), preostalo}
+end
+
+redis.call('HSET', KEYS[1], 'tokeni', tostring(tokeni - 1), 'ts', sada)
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+iskoristeno = redis.call('INCR', KEYS[2])
+if iskoristeno == 1 then
+	redis.call('EXPIRE', KEYS[2], 172800)
+end
+if dnevniLimit > 0 then preostalo = dnevniLimit - iskoristeno end
+return {1, 0, preostalo}
+`)
+
+// AIKvotaRezultat - ishod provjere kvote za jedan AI zahtjev
+type AIKvotaRezultat struct {
+	Dozvoljeno     bool
+	DnevniLimit    bool          // odbijeno jer je potrošen dnevni limit
+	PokusajZa      time.Duration // kada ponovno pokušati
+	PreostaloDanas int           // -1 ako dnevni limit nije postavljen
+}
+
+func envInt(kljuc string, zadano int) int {
+	v, err := strconv.Atoi(config.GetEnv(kljuc, strconv.Itoa(zadano)))
+	if err != nil || v < 0 {
+		return zadano
+	}
+	return v
+}
+
+func danasnjiDatum() string {
+	return time.Now().Format("2006-01-02")
+}
+
+// DoPonoci - vrijeme do resetiranja dnevnih brojača
+func DoPonoci() time.Duration {
+	sutra := time.Now().AddDate(0, 0, 1)
+	return time.Until(time.Date(sutra.Year(), sutra.Month(), sutra.Day(), 0, 0, 0, 0, sutra.Location()))
+}
+
+// ProvjeriAIKvotu - troši jedan token iz klijentovog bucketa (AI_KVOTA_KAPACITET, AI_KVOTA_PO_MINUTI)
+// i jedan poziv od dnevnog limita (AI_KVOTA_DNEVNO, 0 = bez limita)
+func ProvjeriAIKvotu(ctx context.Context, klijent string) (AIKvotaRezultat, error) {
+	kapacitet := envInt("AI_KVOTA_KAPACITET", 5)
+	poMinuti := envInt("AI_KVOTA_PO_MINUTI", 5)
+	dnevno := envInt("AI_KVOTA_DNEVNO", 100)
+	if kapacitet == 0 || poMinuti == 0 {
+		return AIKvotaRezultat{Dozvoljeno: true, PreostaloDanas: -1}, nil
+	}
+
+	brzina := float64(poMinuti) / float64(time.Minute.Milliseconds())
+	// Pun bucket se obnovi za kapacitet/brzina ms; nakon toga zapis više ne treba
+	trajanje := int64(math.Ceil(float64(kapacitet)/brzina)) + 1000
+
+	rez, err := aiKvotaSkripta.Run(ctx, database.GetRedisClient(),
+		[]string{aiKvotaBucketPrefix + klijent, aiKvotaDnevnoPrefix + danasnjiDatum() + ":" + klijent},
+		kapacitet, strconv.FormatFloat(brzina, 'f', -1, 64), time.Now().UnixMilli(), dnevno, trajanje,
+	).Int64Slice()
+	if err != nil {
+		return AIKvotaRezultat{}, fmt.Errorf("greška pri provjeri AI kvote: %w", err)
+	}
+
+	rezultat := AIKvotaRezultat{Dozvoljeno: rez[0] == 1, PreostaloDanas: int(rez[2])}
+	if rez[1] < 0 {
+		rezultat.DnevniLimit = true
+		rezultat.PokusajZa = DoPonoci()
+	} else {
+		rezultat.PokusajZa = time.Duration(rez[1]) * time.Millisecond
+	}
+	return rezultat, nil
+}
+
+// klijentKljuc - ključ konteksta pod kojim handleri spremaju identitet klijenta
+type klijentKljuc struct{}
+
+// SKlijentom - kontekst s identitetom klijenta (za kvote i evidenciju potrošnje)
+func SKlijentom(ctx context.Context, klijent string) context.Context {
+	return context.WithValue(ctx, klijentKljuc{}, klijent)
+}
+
+// KlijentIzKonteksta - identitet klijenta ili "nepoznato"
+func KlijentIzKonteksta(ctx context.Context) string {
+	if k, ok := ctx.Value(klijentKljuc{}).(string); ok && k != "" {
+		return k
+	}
+	return "nepoznato"
+}
+
+// ZabiljeziPotrosnju - dodaje jedan poziv modela i njegove tokene u dnevnu evidenciju.
+// Greške se samo logiraju jer evidencija ne smije srušiti odgovor korisniku.
+func ZabiljeziPotrosnju(ctx context.Context, provider string, p TokenPotrosnja) {
+	rdb := database.GetRedisClient()
+	kljuc := aiPotrosnjaPrefix + danasnjiDatum()
+	klijenti := kljuc + ":klijenti"
+	_, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, kljuc, "pozivi", 1)
+		pipe.HIncrBy(ctx, kljuc, "ulaz", int64(p.Ulaz))
+		pipe.HIncrBy(ctx, kljuc, "izlaz", int64(p.Izlaz))
+		pipe.HIncrBy(ctx, kljuc, "ukupno", int64(p.Ukupno))
+		pipe.HIncrBy(ctx, kljuc, provider+":pozivi", 1)
+		pipe.HIncrBy(ctx, kljuc, provider+":ukupno", int64(p.Ukupno))
+		pipe.ZIncrBy(ctx, klijenti, float64(p.Ukupno), KlijentIzKonteksta(ctx))
+		pipe.Expire(ctx, kljuc, aiPotrosnjaTrajanje)
+		pipe.Expire(ctx, klijenti, aiPotrosnjaTrajanje)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Greška pri evidenciji AI potrošnje: %v", err)
+	}
+}
+
+// ProviderPotrosnja - pozivi i tokeni jednog providera u danu
+type ProviderPotrosnja struct {
+	Pozivi int64 `json:"pozivi"`
+	Tokeni int64 `json:"tokeni"`
+}
+
+// KlijentPotrosnja - tokeni jednog klijenta u danu
+type KlijentPotrosnja struct {
+	Klijent string `json:"klijent"`
+	Tokeni  int64  `json:"tokeni"`
+}
+
+// DnevnaPotrosnja - sažetak AI poziva za jedan dan
+type DnevnaPotrosnja struct {
+	Datum           string                       `json:"datum"`
+	Pozivi          int64                        `json:"pozivi"`
+	TokeniUlaz      int64                        `json:"tokeniUlaz"`
+	TokeniIzlaz     int64                        `json:"tokeniIzlaz"`
+	TokeniUkupno    int64                        `json:"tokeniUkupno"`
+	ProcijenjeniUSD float64                      `json:"procijenjeniTrosakUSD"`
+	PoProvideru     map[string]ProviderPotrosnja `json:"poProvideru"`
+	NajveciKlijenti []KlijentPotrosnja           `json:"najveciKlijenti"`
+}
+
+// cijenaPoMilijunu - cijena u USD za milijun tokena iz konfiguracije
+func cijenaPoMilijunu(kljuc string, zadano float64) float64 {
+	v, err := strconv.ParseFloat(config.GetEnv(kljuc, ""), 64)
+	if err != nil || v < 0 {
+		return zadano
+	}
+	return v
+}
+
+// SazetakPotrosnje - pozivi, tokeni i procijenjeni trošak za zadnjih n dana (od danas unatrag).
+// Cijena: AI_CIJENA_ULAZ_1M i AI_CIJENA_IZLAZ_1M (USD za milijun tokena).
+func SazetakPotrosnje(ctx context.Context, dana int) ([]DnevnaPotrosnja, error) {
+	rdb := database.GetRedisClient()
+	cijenaUlaz := cijenaPoMilijunu("AI_CIJENA_ULAZ_1M", 0.075)
+	cijenaIzlaz := cijenaPoMilijunu("AI_CIJENA_IZLAZ_1M", 0.30)
+
+	result := make([]DnevnaPotrosnja, 0, dana)
+	for i := 0; i < dana; i++ {
+		datum := time.Now().AddDate(0, 0, -i).Format("2006-01-02")
+		kljuc := aiPotrosnjaPrefix + datum
+
+		polja, err := rdb.HGetAll(ctx, kljuc).Result()
+		if err != nil {
+			return nil, fmt.Errorf("greška pri čitanju AI potrošnje: %w", err)
+		}
+		broj := func(polje string) int64 {
+			n, _ := strconv.ParseInt(polja[polje], 10, 64)
+			return n
+		}
+
+		dan := DnevnaPotrosnja{
+			Datum:        datum,
+			Pozivi:       broj("pozivi"),
+			TokeniUlaz:   broj("ulaz"),
+			TokeniIzlaz:  broj("izlaz"),
+			TokeniUkupno: broj("ukupno"),
+			PoProvideru:  make(map[string]ProviderPotrosnja),
+		}
+		dan.ProcijenjeniUSD = math.Round((float64(dan.TokeniUlaz)*cijenaUlaz+float64(dan.TokeniIzlaz)*cijenaIzlaz)/1e6*10000) / 10000
+
+		for polje := range polja {
+			if provider, ok := strings.CutSuffix(polje, ":pozivi"); ok {
+				dan.PoProvideru[provider] = ProviderPotrosnja{
+					Pozivi: broj(polje),
+					Tokeni: broj(provider + ":ukupno"),
+				}
+			}
+		}
+
+		klijenti, err := rdb.ZRevRangeWithScores(ctx, kljuc+":klijenti", 0, aiTopKlijenata-1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("greška pri čitanju AI potrošnje: %w", err)
+		}
+		dan.NajveciKlijenti = make([]KlijentPotrosnja, 0, len(klijenti))
+		for _, k := range klijenti {
+			dan.NajveciKlijenti = append(dan.NajveciKlijenti, KlijentPotrosnja{Klijent: fmt.Sprint(k.Member), Tokeni: int64(k.Score)})
+		}
+
+		result = append(result, dan)
+	}
+	return result, nil
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+	"time"
+
+	"github.com/ddobren/eduformacije/config"
+	"github.com/ddobren/eduformacije/database"
+	"github.com/redis/go-redis/v9"
+)
+
+// Anonimne sesije za javni frontend: server izdaje potpisani izazov, preglednik pronađe
+// rješenje (proof-of-work) i dobiva kratkotrajni token vezan uz sesiju, a ne uz IP adresu.
+//
+//	AUTH_POW_TEZINA       - broj vodećih nul-bitova u SHA-256(izazov:rjesenje) (default 16)
+//	AUTH_IZAZOV_TTL       - koliko dugo izazov vrijedi (default 2m)
+//	AUTH_SESIJA_TTL       - trajanje tokena anonimne sesije (default 30m)
+//	AUTH_DOZVOLJENI_ORIGINI - ako je postavljeno, izazov se izdaje samo za te Origin vrijednosti
+//	AUTH_SESIJE_PO_IP_SATNO - najviše novih sesija s jedne IP adrese u satu (default 20, 0 = bez ograničenja)
+//
+// Izazovi se potpisuju ključem izvedenim iz API_SECRET, koji mora imati barem minDuljinaTajne znakova.
+const (
+	zadanaPowTezina = 16
+	minPowTezina    = 1
+	maxPowTezina    = 32
+	minDuljinaTajne = 32
+
+	izazovIskoristenPrefix = "auth:izazov:iskoristen:"
+	sesijeIPPrefix         = "auth:sesije:ip:"
+
+	// AnonimniPrefix - "sub" anonimne sesije je AnonimniPrefix + ID sesije
+	AnonimniPrefix = "anon:"
+)
+
+// Greške pri provjeri izazova; poruke su sigurne za prikaz korisniku
+var (
+	ErrNeispravanIzazov   = errors.New("neispravan izazov")
+	ErrIzazovIstekao      = errors.New("izazov je istekao, zatraži novi")
+	ErrIzazovIskoristen   = errors.New("izazov je već iskorišten")
+	ErrKrivoRjesenje      = errors.New("rješenje izazova nije ispravno")
+	ErrOriginNijeDopusten = errors.New("origin nije dopušten")
+	ErrPreviseSesija      = errors.New("previše novih sesija s ove adrese, pokušaj kasnije")
+)
+
+// ErrSlabaTajna - API_SECRET nije postavljen ili je prekratak za potpisivanje
+var ErrSlabaTajna = fmt.Errorf("API_SECRET mora imati barem %d znaka", minDuljinaTajne)
+
+// izazovPodaci - sadržaj potpisanog izazova
+type izazovPodaci struct {
+	Nonce  string `json:"n"`
+	Istek  int64  `json:"e"`
+	Tezina int    `json:"d"`
+	Origin string `json:"o,omitempty"`
+}
+
+// Izazov - izazov koji preglednik treba riješiti
+type Izazov struct {
+	Izazov string    `json:"izazov"`
+	Tezina int       `json:"tezina"`
+	Istice time.Time `json:"istice"`
+}
+
+// apiTajna - API_SECRET; ErrSlabaTajna ako je prazan ili prekratak
+func apiTajna() ([]byte, error) {
+	tajna := config.GetEnv("API_SECRET", "")
+	if len(tajna) < minDuljinaTajne {
+		return nil, ErrSlabaTajna
+	}
+	return []byte(tajna), nil
+}
+
+// ProvjeriTajnuIzazova - poziva se pri pokretanju; bez ispravnog API_SECRET izazovi se ne mogu potpisati
+func ProvjeriTajnuIzazova() error {
+	_, err := apiTajna()
+	return err
+}
+
+// izazovKljuc - ključ za potpis izazova, izveden iz API_SECRET
+func izazovKljuc() ([]byte, error) {
+	tajna, err := apiTajna()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, tajna)
+	mac.Write([]byte("eduformacije/izazov"))
+	return mac.Sum(nil), nil
+}
+
+func potpisIzazova(kljuc []byte, payload string) string {
+	mac := hmac.New(sha256.New, kljuc)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// powTezina - AUTH_POW_TEZINA ograničen na [minPowTezina, maxPowTezina]
+func powTezina() int {
+	tezina := envInt("AUTH_POW_TEZINA", zadanaPowTezina)
+	if tezina < minPowTezina {
+		return minPowTezina
+	}
+	if tezina > maxPowTezina {
+		return maxPowTezina
+	}
+	return tezina
+}
+
+// OriginDopusten - prazan AUTH_DOZVOLJENI_ORIGINI dopušta sve (npr. lokalni razvoj)
+func OriginDopusten(origin string) bool {
+	dopusteni := strings.TrimSpace(config.GetEnv("AUTH_DOZVOLJENI_ORIGINI", ""))
+	if dopusteni == "" {
+		return true
+	}
+	for _, o := range strings.Split(dopusteni, ",") {
+		if strings.TrimSpace(o) == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// NoviIzazov - potpisani izazov vezan uz Origin preglednika. Server ne pamti izdane izazove.
+func NoviIzazov(origin string) (Izazov, error) {
+	if !OriginDopusten(origin) {
+		return Izazov{}, ErrOriginNijeDopusten
+	}
+	kljuc, err := izazovKljuc()
+	if err != nil {
+		return Izazov{}, err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return Izazov{}, err
+	}
+	istek := time.Now().Add(envTrajanje("AUTH_IZAZOV_TTL", 2*time.Minute))
+	podaci := izazovPodaci{
+		Nonce:  hex.EncodeToString(nonce),
+		Istek:  istek.Unix(),
+		Tezina: powTezina(),
+		Origin: origin,
+	}
+
+	data, err := json.Marshal(podaci)
+	if err != nil {
+		return Izazov{}, err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return Izazov{
+		Izazov: payload + "." + potpisIzazova(kljuc, payload),
+		Tezina: podaci.Tezina,
+		Istice: time.Unix(podaci.Istek, 0),
+	}, nil
+}
+
+// vodeceNule - broj vodećih nul-bitova u hashu
+func vodeceNule(h []byte) int {
+	n := 0
+	for _, b := range h {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
+
+// provjeriIzazov - potpis, istek, origin i proof-of-work; vraća podatke izazova
+func provjeriIzazov(izazov, rjesenje, origin string) (izazovPodaci, error) {
+	kljuc, err := izazovKljuc()
+	if err != nil {
+		return izazovPodaci{}, err
+	}
+
+	payload, potpis, ok := strings.Cut(izazov, ".")
+	if !ok || !hmac.Equal([]byte(potpis), []byte(potpisIzazova(kljuc, payload))) {
+		return izazovPodaci{}, ErrNeispravanIzazov
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return izazovPodaci{}, ErrNeispravanIzazov
+	}
+	var podaci izazovPodaci
+	if err := json.Unmarshal(data, &podaci); err != nil {
+		return izazovPodaci{}, ErrNeispravanIzazov
+	}
+
+	if podaci.Tezina < minPowTezina {
+		return izazovPodaci{}, ErrNeispravanIzazov
+	}
+	if time.Now().Unix() > podaci.Istek {
+		return izazovPodaci{}, ErrIzazovIstekao
+	}
+	if podaci.Origin != origin {
+		return izazovPodaci{}, ErrOriginNijeDopusten
+	}
+
+	if len(rjesenje) == 0 || len(rjesenje) > 64 {
+		return izazovPodaci{}, ErrKrivoRjesenje
+	}
+	h := sha256.Sum256([]byte(izazov + ":" + rjesenje))
+	if vodeceNule(h[:]) < podaci.Tezina {
+		return izazovPodaci{}, ErrKrivoRjesenje
+	}
+	return podaci, nil
+}
+
+// DoSljedecegSata - vrijeme do resetiranja satnog brojača sesija po IP adresi
+func DoSljedecegSata() time.Duration {
+	return time.Until(time.Now().Truncate(time.Hour).Add(time.Hour))
+}
+
+// zabiljeziSesijuZaIP - broji sesije otvorene s IP adrese u tekućem satu. Svaka sesija ima
+// novi "sub" i vlastite limite, pa bez ovoga jedna adresa može otvarati sesije bez kraja.
+func zabiljeziSesijuZaIP(ctx context.Context, ip string) error {
+	limit := envInt("AUTH_SESIJE_PO_IP_SATNO", 20)
+	if limit == 0 {
+		return nil
+	}
+
+	kljuc := sesijeIPPrefix + time.Now().Truncate(time.Hour).Format("2006010215") + ":" + ip
+	var broj *redis.IntCmd
+	_, err := database.GetRedisClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		broj = pipe.Incr(ctx, kljuc)
+		pipe.Expire(ctx, kljuc, time.Hour)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("greška pri brojanju sesija u Redisu: %w", err)
+	}
+	if broj.Val() > int64(limit) {
+		return ErrPreviseSesija
+	}
+	return nil
+}
+
+// AnonimnaSesija - izdani token anonimne sesije
+type AnonimnaSesija struct {
+	SesijaId string
+	Token    string
+	Istek    time.Time
+}
+
+// OtvoriAnonimnuSesiju - provjerava riješeni izazov i limit sesija za IP adresu, označava izazov
+// iskorištenim (svaki izazov vrijedi za samo jednu sesiju) i izdaje token s ograničenim scope-ovima
+func OtvoriAnonimnuSesiju(ctx context.Context, izazov, rjesenje, origin, ip string) (AnonimnaSesija, error) {
+	podaci, err := provjeriIzazov(izazov, rjesenje, origin)
+	if err != nil {
+		return AnonimnaSesija{}, err
+	}
+	if err := zabiljeziSesijuZaIP(ctx, ip); err != nil {
+		return AnonimnaSesija{}, err
+	}
+
+	preostalo := time.Until(time.Unix(podaci.Istek, 0)) + time.Second
+	novi, err := database.GetRedisClient().SetNX(ctx, izazovIskoristenPrefix+podaci.Nonce, 1, preostalo).Result()
+	if err != nil {
+		return AnonimnaSesija{}, fmt.Errorf("greška pri provjeri izazova u Redisu: %w", err)
+	}
+	if !novi {
+		return AnonimnaSesija{}, ErrIzazovIskoristen
+	}
+
+	sesijaId, err := novaSesijaId()
+	if err != nil {
+		return AnonimnaSesija{}, fmt.Errorf("greška pri generiranju ID-a sesije: %w", err)
+	}
+
+	klijent := APIKlijent{Id: AnonimniPrefix + sesijaId}
+	token, istek, err := izdajToken(klijent, []string{ScopeCitanjeSkola, ScopeAISugestije}, envTrajanje("AUTH_SESIJA_TTL", 30*time.Minute))
+	if err != nil {
+		return AnonimnaSesija{}, err
+	}
+	return AnonimnaSesija{SesijaId: sesijaId, Token: token, Istek: istek}, nil
+}
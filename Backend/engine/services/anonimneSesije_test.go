@@ -0,0 +1,163 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testnaTajna = "0123456789abcdef0123456789abcdef"
+
+func TestVodeceNule(t *testing.T) {
+	slucajevi := []struct {
+		h    []byte
+		ocek int
+	}{
+		{[]byte{0x80}, 0},
+		{[]byte{0x40}, 1},
+		{[]byte{0x01}, 7},
+		{[]byte{0x00, 0xff}, 8},
+		{[]byte{0x00, 0x00, 0x10}, 19},
+		{[]byte{0x00, 0x00}, 16},
+		{nil, 0},
+	}
+	for _, s := range slucajevi {
+		if dobiveno := vodeceNule(s.h); dobiveno != s.ocek {
+			t.Errorf("vodeceNule(%x) = %d, očekivano %d", s.h, dobiveno, s.ocek)
+		}
+	}
+}
+
+func TestPowTezina(t *testing.T) {
+	slucajevi := map[string]int{
+		"":   zadanaPowTezina,
+		"0":  minPowTezina,
+		"-3": zadanaPowTezina, // neispravna vrijednost
+		"12": 12,
+		"40": maxPowTezina,
+	}
+	for vrijednost, ocek := range slucajevi {
+		t.Setenv("AUTH_POW_TEZINA", vrijednost)
+		if dobiveno := powTezina(); dobiveno != ocek {
+			t.Errorf("AUTH_POW_TEZINA=%q: powTezina() = %d, očekivano %d", vrijednost, dobiveno, ocek)
+		}
+	}
+}
+
+// potpisaniIzazov - izazov s proizvoljnim podacima, potpisan kao da ga je izdao server
+func potpisaniIzazov(t *testing.T, podaci izazovPodaci) string {
+	t.Helper()
+	kljuc, err := izazovKljuc()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(podaci)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + potpisIzazova(kljuc, payload)
+}
+
+// rijesi - prvo rješenje s dovoljno vodećih nul-bitova
+func rijesi(izazov string, tezina int) string {
+	for i := 0; ; i++ {
+		rjesenje := strconv.Itoa(i)
+		h := sha256.Sum256([]byte(izazov + ":" + rjesenje))
+		if vodeceNule(h[:]) >= tezina {
+			return rjesenje
+		}
+	}
+}
+
+// krivoRjesenje - rješenje koje nema dovoljno vodećih nul-bitova
+func krivoRjesenje(izazov string, tezina int) string {
+	for i := 0; ; i++ {
+		rjesenje := strconv.Itoa(i)
+		h := sha256.Sum256([]byte(izazov + ":" + rjesenje))
+		if vodeceNule(h[:]) < tezina {
+			return rjesenje
+		}
+	}
+}
+
+func TestProvjeriIzazov(t *testing.T) {
+	t.Setenv("API_SECRET", testnaTajna)
+	const origin = "https://eduformacije.com"
+	istek := time.Now().Add(time.Minute).Unix()
+
+	ispravan := potpisaniIzazov(t, izazovPodaci{Nonce: "a", Istek: istek, Tezina: 8, Origin: origin})
+	istekao := potpisaniIzazov(t, izazovPodaci{Nonce: "b", Istek: time.Now().Add(-time.Second).Unix(), Tezina: 8, Origin: origin})
+	bezTezine := potpisaniIzazov(t, izazovPodaci{Nonce: "c", Istek: istek, Tezina: 0, Origin: origin})
+	payload, _, _ := strings.Cut(ispravan, ".")
+	krivotvoren := payload + ".krivi-potpis"
+	kljuc, err := izazovKljuc()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slucajevi := []struct {
+		naziv    string
+		izazov   string
+		rjesenje string
+		origin   string
+		greska   error
+	}{
+		{"ispravno rješenje", ispravan, rijesi(ispravan, 8), origin, nil},
+		{"krivo rješenje", ispravan, krivoRjesenje(ispravan, 8), origin, ErrKrivoRjesenje},
+		{"prazno rješenje", ispravan, "", origin, ErrKrivoRjesenje},
+		{"predugo rješenje", ispravan, strings.Repeat("1", 65), origin, ErrKrivoRjesenje},
+		{"drugi origin", ispravan, rijesi(ispravan, 8), "https://napadac.com", ErrOriginNijeDopusten},
+		{"istekao", istekao, rijesi(istekao, 8), origin, ErrIzazovIstekao},
+		{"krivotvoren potpis", krivotvoren, "1", origin, ErrNeispravanIzazov},
+		{"bez potpisa", payload, "1", origin, ErrNeispravanIzazov},
+		{"težina 0 u potpisanom izazovu", bezTezine, "1", origin, ErrNeispravanIzazov},
+		{"nije base64", "!!!." + potpisIzazova(kljuc, "!!!"), "1", origin, ErrNeispravanIzazov},
+	}
+	for _, s := range slucajevi {
+		t.Run(s.naziv, func(t *testing.T) {
+			_, err := provjeriIzazov(s.izazov, s.rjesenje, s.origin)
+			if !errors.Is(err, s.greska) {
+				t.Errorf("provjeriIzazov = %v, očekivano %v", err, s.greska)
+			}
+		})
+	}
+}
+
+func TestIzazovBezTajne(t *testing.T) {
+	for _, tajna := range []string{"", "prekratka-tajna"} {
+		t.Setenv("API_SECRET", tajna)
+		if _, err := NoviIzazov(""); !errors.Is(err, ErrSlabaTajna) {
+			t.Errorf("API_SECRET=%q: NoviIzazov = %v, očekivano ErrSlabaTajna", tajna, err)
+		}
+		if _, err := provjeriIzazov("a.b", "1", ""); !errors.Is(err, ErrSlabaTajna) {
+			t.Errorf("API_SECRET=%q: provjeriIzazov = %v, očekivano ErrSlabaTajna", tajna, err)
+		}
+	}
+}
+
+func TestNoviIzazovOrigin(t *testing.T) {
+	t.Setenv("API_SECRET", testnaTajna)
+	t.Setenv("AUTH_POW_TEZINA", "4")
+	t.Setenv("AUTH_DOZVOLJENI_ORIGINI", "https://eduformacije.com, https://www.eduformacije.com")
+
+	if _, err := NoviIzazov("https://napadac.com"); !errors.Is(err, ErrOriginNijeDopusten) {
+		t.Fatalf("NoviIzazov s nedopuštenim originom = %v", err)
+	}
+
+	izazov, err := NoviIzazov("https://www.eduformacije.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if izazov.Tezina != 4 {
+		t.Errorf("težina = %d, očekivano 4", izazov.Tezina)
+	}
+	if _, err := provjeriIzazov(izazov.Izazov, rijesi(izazov.Izazov, 4), "https://www.eduformacije.com"); err != nil {
+		t.Errorf("izdani izazov nije prošao provjeru: %v", err)
+	}
+}
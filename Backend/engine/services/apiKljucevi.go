@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ddobren/eduformacije/database"
+	"github.com/ddobren/eduformacije/models"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// API ključevi za vanjske integratore (savjetovališta, portali gradova) koji šalju X-API-Key
+// umjesto Bearer tokena. Ključevi su u Mongu, dnevno korištenje u Redisu.
+const (
+	apiKljucPrefix           = "edu_"
+	apiKljucKoristenjePrefix = "apikey:koristenje:" // hash po danu: polje je id ključa
+	apiKljucKoristenjeTTL    = 90 * 24 * time.Hour
+
+	// Zadani limit po sekundi ako ključ nema vlastiti
+	zadaniLimitKljuca = 10
+
+	// Koliko dugo se rezultat provjere ključa pamti u procesu (opoziv na ovoj instanci je odmah)
+	apiKljucCacheTTL = 30 * time.Second
+	apiKljucCacheMax = 10000
+)
+
+// APIKljucSubPrefix - "sub" zahtjeva s API ključem je APIKljucSubPrefix + id ključa
+const APIKljucSubPrefix = "apikey:"
+
+var (
+	ErrNepoznatAPIKljuc  = errors.New("nepoznat ili opozvan API ključ")
+	ErrAPIKljucNePostoji = errors.New("API ključ ne postoji")
+	// ErrNeispravanAPIKljucZahtjev - poruka je sigurna za prikaz administratoru
+	ErrNeispravanAPIKljucZahtjev = errors.New("neispravan zahtjev za API ključ")
+)
+
+func apiKljuceviKolekcija() *mongo.Collection {
+	return database.GetMongoCollection("eduformacije", "apiKljucevi")
+}
+
+// InitAPIKljuceve - jedinstveni indeks na hash ključa. Poziva se nakon InitMongo.
+func InitAPIKljuceve() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := apiKljuceviKolekcija().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Greška pri stvaranju indeksa za API ključeve: %v", err)
+	}
+}
+
+func hashAPIKljuca(kljuc string) string {
+	h := sha256.Sum256([]byte(kljuc))
+	return hex.EncodeToString(h[:])
+}
+
+// normalizirajAPIKljuc - provjera i zadane vrijednosti zahtjeva za novi ključ
+func normalizirajAPIKljuc(req models.APIKljucRequest) (models.APIKljuc, error) {
+	k := models.APIKljuc{
+		Naziv:             strings.TrimSpace(req.Naziv),
+		Scope:             req.Scope,
+		LimitPoSekundi:    req.LimitPoSekundi,
+		DnevniLimit:       req.DnevniLimit,
+		DozvoljeniOrigini: req.DozvoljeniOrigini,
+		DozvoljeneRute:    req.DozvoljeneRute,
+	}
+	if k.Naziv == "" {
+		return k, fmt.Errorf("%w: naziv je obavezan", ErrNeispravanAPIKljucZahtjev)
+	}
+	if len(k.Scope) == 0 {
+		k.Scope = []string{ScopeCitanjeSkola}
+	}
+	for _, s := range k.Scope {
+		if !poznatiScopeovi[s] {
+			return k, fmt.Errorf("%w: nepoznat scope %s", ErrNeispravanAPIKljucZahtjev, s)
+		}
+	}
+	if k.LimitPoSekundi < 0 || k.DnevniLimit < 0 {
+		return k, fmt.Errorf("%w: limiti ne mogu biti negativni", ErrNeispravanAPIKljucZahtjev)
+	}
+	if k.LimitPoSekundi == 0 {
+		k.LimitPoSekundi = zadaniLimitKljuca
+	}
+	for _, r := range k.DozvoljeneRute {
+		if !strings.HasPrefix(r, "/api/v1/") {
+			return k, fmt.Errorf("%w: ruta %s mora počinjati s /api/v1/", ErrNeispravanAPIKljucZahtjev, r)
+		}
+	}
+	if k.DozvoljeniOrigini == nil {
+		k.DozvoljeniOrigini = []string{}
+	}
+	if k.DozvoljeneRute == nil {
+		k.DozvoljeneRute = []string{}
+	}
+	return k, nil
+}
+
+// KreirajAPIKljuc - sprema novi ključ i vraća ga u čitljivom obliku (jedini put kad je dostupan)
+func KreirajAPIKljuc(ctx context.Context, req models.APIKljucRequest) (string, models.APIKljuc, error) {
+	k, err := normalizirajAPIKljuc(req)
+	if err != nil {
+		return "", k, err
+	}
+
+	tajna := make([]byte, 24)
+	if _, err := rand.Read(tajna); err != nil {
+		return "", k, err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", k, err
+	}
+
+	kljuc := apiKljucPrefix + base64.RawURLEncoding.EncodeToString(tajna)
+	k.Id = hex.EncodeToString(id)
+	k.Prefiks = kljuc[:len(apiKljucPrefix)+6]
+	k.Hash = hashAPIKljuca(kljuc)
+	k.Kreiran = time.Now().UTC()
+
+	if _, err := apiKljuceviKolekcija().InsertOne(ctx, k); err != nil {
+		return "", k, fmt.Errorf("greška pri spremanju API ključa: %w", err)
+	}
+	return kljuc, k, nil
+}
+
+// PopisAPIKljuceva - svi ključevi (i opozvani) s današnjim brojem zahtjeva
+func PopisAPIKljuceva(ctx context.Context) ([]models.APIKljuc, error) {
+	cursor, err := apiKljuceviKolekcija().Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "kreiran", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("greška pri dohvaćanju API ključeva: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	kljucevi := []models.APIKljuc{}
+	if err := cursor.All(ctx, &kljucevi); err != nil {
+		return nil, fmt.Errorf("greška pri čitanju API ključeva: %w", err)
+	}
+
+	danas, err := database.GetRedisClient().HGetAll(ctx, apiKljucKoristenjePrefix+danasnjiDatum()).Result()
+	if err != nil {
+		log.Printf("Greška pri dohvaćanju korištenja API ključeva: %v", err)
+	}
+	for i := range kljucevi {
+		kljucevi[i].KoristenoDanas, _ = strconv.ParseInt(danas[kljucevi[i].Id], 10, 64)
+	}
+	return kljucevi, nil
+}
+
+// OpozoviAPIKljuc - ključ ostaje zapisan (zbog statistike), ali više ne vrijedi
+func OpozoviAPIKljuc(ctx context.Context, id string) error {
+	sada := time.Now().UTC()
+	res, err := apiKljuceviKolekcija().UpdateOne(ctx,
+		bson.M{"_id": id, "opozvan": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"opozvan": sada}},
+	)
+	if err != nil {
+		return fmt.Errorf("greška pri opozivu API ključa: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrAPIKljucNePostoji
+	}
+	apiKljucCache.obrisiSve()
+	return nil
+}
+
+// apiKljucCacheUnos - rezultat provjere; nil kljuc znači nepoznat ili opozvan ključ
+type apiKljucCacheUnos struct {
+	kljuc   *models.APIKljuc
+	vrijedi time.Time
+}
+
+// apiKljucCacheMapa - da svaki zahtjev s ključem ne ide u Mongo
+type apiKljucCacheMapa struct {
+	mu    sync.Mutex
+	unosi map[string]apiKljucCacheUnos
+}
+
+var apiKljucCache = &apiKljucCacheMapa{unosi: map[string]apiKljucCacheUnos{}}
+
+func (m *apiKljucCacheMapa) dohvati(hash string) (apiKljucCacheUnos, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.unosi[hash]
+	if !ok || time.Now().After(u.vrijedi) {
+		return apiKljucCacheUnos{}, false
+	}
+	return u, true
+}
+
+func (m *apiKljucCacheMapa) spremi(hash string, k *models.APIKljuc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.unosi) >= apiKljucCacheMax {
+		m.unosi = map[string]apiKljucCacheUnos{}
+	}
+	m.unosi[hash] = apiKljucCacheUnos{kljuc: k, vrijedi: time.Now().Add(apiKljucCacheTTL)}
+}
+
+func (m *apiKljucCacheMapa) obrisiSve() {
+	m.mu.Lock()
+	m.unosi = map[string]apiKljucCacheUnos{}
+	m.mu.Unlock()
+}
+
+// ProvjeriAPIKljuc - vraća aktivni ključ za vrijednost X-API-Key zaglavlja
+func ProvjeriAPIKljuc(ctx context.Context, kljuc string) (*models.APIKljuc, error) {
+	if !strings.HasPrefix(kljuc, apiKljucPrefix) || len(kljuc) > 64 {
+		return nil, ErrNepoznatAPIKljuc
+	}
+	hash := hashAPIKljuca(kljuc)
+	if u, ok := apiKljucCache.dohvati(hash); ok {
+		if u.kljuc == nil {
+			return nil, ErrNepoznatAPIKljuc
+		}
+		return u.kljuc, nil
+	}
+
+	var k models.APIKljuc
+	err := apiKljuceviKolekcija().FindOne(ctx, bson.M{"hash": hash}).Decode(&k)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && k.Opozvan != nil) {
+		apiKljucCache.spremi(hash, nil)
+		return nil, ErrNepoznatAPIKljuc
+	}
+	if err != nil {
+		return nil, fmt.Errorf("greška pri provjeri API ključa: %w", err)
+	}
+	apiKljucCache.spremi(hash, &k)
+	return &k, nil
+}
+
+// APIKljucDozvoljavaOrigin - provjerava se samo kad preglednik pošalje Origin;
+// pozivi sa servera ga nemaju pa ih ograničavaju rute i limiti
+func APIKljucDozvoljavaOrigin(k *models.APIKljuc, origin string) bool {
+	if len(k.DozvoljeniOrigini) == 0 || origin == "" {
+		return true
+	}
+	for _, o := range k.DozvoljeniOrigini {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKljucDozvoljavaRutu - ruta je gin obrazac (npr. /api/v1/srednje-skole/skole/:id);
+// unos koji završava s * dozvoljava sve rute s tim početkom
+func APIKljucDozvoljavaRutu(k *models.APIKljuc, ruta string) bool {
+	if len(k.DozvoljeneRute) == 0 {
+		return true
+	}
+	for _, r := range k.DozvoljeneRute {
+		if prefiks, ok := strings.CutSuffix(r, "*"); ok && strings.HasPrefix(ruta, prefiks) {
+			return true
+		}
+		if r == ruta {
+			return true
+		}
+	}
+	return false
+}
+
+// ZabiljeziKoristenjeKljuca - povećava današnji brojač i vraća novu vrijednost
+func ZabiljeziKoristenjeKljuca(ctx context.Context, id string) (int64, error) {
+	kljuc := apiKljucKoristenjePrefix + danasnjiDatum()
+	var broj *redis.IntCmd
+	_, err := database.GetRedisClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		broj = pipe.HIncrBy(ctx, kljuc, id, 1)
+		pipe.Expire(ctx, kljuc, apiKljucKoristenjeTTL)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return broj.Val(), nil
+}
+
+// DnevnoKoristenje - broj zahtjeva s ključem u jednom danu
+type DnevnoKoristenje struct {
+	Datum    string `json:"datum"`
+	Zahtjeva int64  `json:"zahtjeva"`
+}
+
+// KoristenjeKljuca - zadnjih dana dana, od danas unatrag
+func KoristenjeKljuca(ctx context.Context, id string, dana int) ([]DnevnoKoristenje, error) {
+	rdb := database.GetRedisClient()
+	rezultat := make([]DnevnoKoristenje, 0, dana)
+	for i := 0; i < dana; i++ {
+		datum := time.Now().AddDate(0, 0, -i).Format("2006-01-02")
+		broj, err := rdb.HGet(ctx, apiKljucKoristenjePrefix+datum, id).Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("greška pri dohvaćanju korištenja API ključa: %w", err)
+		}
+		rezultat = append(rezultat, DnevnoKoristenje{Datum: datum, Zahtjeva: broj})
+	}
+	return rezultat, nil
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ddobren/eduformacije/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditZapis - jedna administratorska akcija (kolekcija auditLog)
+type AuditZapis struct {
+	Vrijeme time.Time              `bson:"vrijeme" json:"vrijeme"`
+	Akter   string                 `bson:"akter" json:"akter"` // "sub" iz tokena
+	IP      string                 `bson:"ip" json:"ip"`
+	Metoda  string                 `bson:"metoda" json:"metoda"`
+	Ruta    string                 `bson:"ruta" json:"ruta"`
+	Putanja string                 `bson:"putanja" json:"putanja"`
+	Status  int                    `bson:"status" json:"status"`
+	Detalji map[string]interface{} `bson:"detalji,omitempty" json:"detalji,omitempty"`
+}
+
+func auditKolekcija() *mongo.Collection {
+	return database.GetMongoCollection("eduformacije", "auditLog")
+}
+
+// ZabiljeziAudit - sprema zapis; greška se samo logira da audit ne obori akciju koja je već izvršena
+func ZabiljeziAudit(z AuditZapis) {
+	log.Printf("AUDIT %s %s %s (akter=%s, ip=%s, status=%d, detalji=%v)", z.Metoda, z.Putanja, z.Ruta, z.Akter, z.IP, z.Status, z.Detalji)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := auditKolekcija().InsertOne(ctx, z); err != nil {
+		log.Printf("Greška pri spremanju audit zapisa: %v", err)
+	}
+}
+
+// ZadnjiAuditZapisi - najnoviji zapisi, od najnovijeg
+func ZadnjiAuditZapisi(ctx context.Context, limit int) ([]AuditZapis, error) {
+	cursor, err := auditKolekcija().Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "vrijeme", Value: -1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, fmt.Errorf("greška pri dohvaćanju audit zapisa: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	zapisi := []AuditZapis{}
+	if err := cursor.All(ctx, &zapisi); err != nil {
+		return nil, fmt.Errorf("greška pri čitanju audit zapisa: %w", err)
+	}
+	return zapisi, nil
+}
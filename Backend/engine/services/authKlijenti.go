@@ -0,0 +1,128 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ddobren/eduformacije/config"
+)
+
+// Scope-ovi pristupnih tokena
+const (
+	ScopeCitanjeSkola = "read:skole"   // podaci o školama i programima
+	ScopeAISugestije  = "ai:sugestije" // AI preporuke i savjetnik
+)
+
+// poznatiScopeovi - scope-ovi koje klijent smije imati u registru
+var poznatiScopeovi = map[string]bool{
+	ScopeCitanjeSkola: true,
+	ScopeAISugestije:  true,
+}
+
+// ErrNeispravniPodaciKlijenta - nepoznat klijent ili kriva tajna (namjerno se ne razlikuju)
+var ErrNeispravniPodaciKlijenta = errors.New("neispravni podaci klijenta")
+
+// APIKlijent - registrirani klijent iz AUTH_KLIJENTI_DATOTEKA.
+// Tajna se ne čuva, samo njen SHA-256 hash (npr. `echo -n tajna | sha256sum`).
+type APIKlijent struct {
+	Id           string   `json:"id"`
+	Naziv        string   `json:"naziv"`
+	SecretSha256 string   `json:"secretSha256"`
+	Scope        []string `json:"scope"`
+	Uloga        string   `json:"uloga,omitempty"` // npr. "admin" za /api/v1/admin
+}
+
+var (
+	klijentiMu sync.RWMutex
+	klijenti   = map[string]APIKlijent{}
+)
+
+// UcitajKlijente - čita registar klijenata (JSON polje APIKlijent). Ako datoteka ne postoji,
+// nijedan klijent ne može dobiti token, ali server radi. Poziva se pri pokretanju.
+func UcitajKlijente() error {
+	putanja := config.GetEnv("AUTH_KLIJENTI_DATOTEKA", "auth-klijenti.json")
+
+	data, err := os.ReadFile(putanja)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("Registar klijenata %s ne postoji, izdavanje tokena je isključeno", putanja)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("greška pri čitanju registra klijenata: %w", err)
+	}
+
+	var popis []APIKlijent
+	if err := json.Unmarshal(data, &popis); err != nil {
+		return fmt.Errorf("neispravan registar klijenata %s: %w", putanja, err)
+	}
+
+	ucitani := make(map[string]APIKlijent, len(popis))
+	for _, k := range popis {
+		if k.Id == "" {
+			return fmt.Errorf("registar klijenata: klijent bez id-a")
+		}
+		if _, postoji := ucitani[k.Id]; postoji {
+			return fmt.Errorf("registar klijenata: klijent %s je naveden više puta", k.Id)
+		}
+		if h, err := hex.DecodeString(k.SecretSha256); err != nil || len(h) != sha256.Size {
+			return fmt.Errorf("registar klijenata: klijent %s nema ispravan secretSha256", k.Id)
+		}
+		for _, s := range k.Scope {
+			if !poznatiScopeovi[s] {
+				return fmt.Errorf("registar klijenata: klijent %s ima nepoznat scope %s", k.Id, s)
+			}
+		}
+		k.SecretSha256 = strings.ToLower(k.SecretSha256)
+		ucitani[k.Id] = k
+	}
+
+	klijentiMu.Lock()
+	klijenti = ucitani
+	klijentiMu.Unlock()
+
+	log.Printf("Učitano %d API klijenata iz %s", len(ucitani), putanja)
+	return nil
+}
+
+// ProvjeriKlijenta - vraća klijenta ako se tajna podudara s hashom iz registra
+func ProvjeriKlijenta(id, tajna string) (APIKlijent, error) {
+	klijentiMu.RLock()
+	k, ok := klijenti[id]
+	klijentiMu.RUnlock()
+
+	hash := sha256.Sum256([]byte(tajna))
+	ocekivano, _ := hex.DecodeString(k.SecretSha256)
+	// Usporedba se radi i za nepoznatog klijenta da vrijeme odgovora ne otkriva postoji li
+	if subtle.ConstantTimeCompare(hash[:], ocekivano) != 1 || !ok {
+		return APIKlijent{}, ErrNeispravniPodaciKlijenta
+	}
+	return k, nil
+}
+
+// OdaberiScope - traženi scope-ovi (odvojeni razmakom) moraju biti podskup dozvoljenih;
+// prazan zahtjev dobiva sve dozvoljene
+func OdaberiScope(k APIKlijent, trazeno string) ([]string, error) {
+	if strings.TrimSpace(trazeno) == "" {
+		return k.Scope, nil
+	}
+	dozvoljeno := make(map[string]bool, len(k.Scope))
+	for _, s := range k.Scope {
+		dozvoljeno[s] = true
+	}
+	var scope []string
+	for _, s := range strings.Fields(trazeno) {
+		if !dozvoljeno[s] {
+			return nil, fmt.Errorf("scope %s nije dozvoljen za ovog klijenta", s)
+		}
+		scope = append(scope, s)
+	}
+	return scope, nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+
+	"github.com/ddobren/eduformacije/models"
+)
+
+// fakeLLMProvider - deterministički provider bez mreže (staging, lokalni razvoj).
+// Iz prompta uzima prvih nekoliko ponuđenih programa i vraća ih u JSON formatu zadanom shemom.
+type fakeLLMProvider struct{}
+
+const fakeBrojPrograma = 3
+
+var fakeProgramRegex = regexp.MustCompile(`"skolaProgramRokId":"([^"]*)","program":"((?:[^"\\]|\\.)*)"`)
+
+func (fakeLLMProvider) Naziv() string { return "fake" }
+
+func (fakeLLMProvider) Generate(ctx context.Context, req LLMRequest) (LLMResponse, error) {
+	programi := []models.ProgramWithID{}
+	for _, m := range fakeProgramRegex.FindAllStringSubmatch(req.Prompt, fakeBrojPrograma) {
+		var program string
+		if err := json.Unmarshal([]byte(`"`+m[2]+`"`), &program); err != nil {
+			program = m[2]
+		}
+		programi = append(programi, models.ProgramWithID{
+			SkolaProgramRokId: m[1],
+			Program:           program,
+			Razlog:            "Testni razlog (fake LLM provider).",
+			Podudaranje:       0.5,
+		})
+	}
+
+	// Odgovor slažemo prema shemi: "programi" dobiva programe iz prompta, ostali stringovi testni tekst
+	shema := req.Shema
+	if shema == nil {
+		shema = sugestijeShema
+	}
+	odgovor := make(map[string]interface{}, len(shema.Properties))
+	for kljuc, polje := range shema.Properties {
+		switch {
+		case kljuc == "programi":
+			odgovor[kljuc] = programi
+		case polje.Type == ShemaString && polje.NePrazno:
+			odgovor[kljuc] = "Ovo su testni prijedlozi (fake LLM provider) 🙂"
+		case polje.Type == ShemaString:
+			odgovor[kljuc] = ""
+		}
+	}
+
+	data, err := json.Marshal(odgovor)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+	return LLMResponse{Tekst: string(data)}, nil
+}
+
+// GenerateStream - vraća isti odgovor kao Generate, podijeljen na male dijelove
+func (f fakeLLMProvider) GenerateStream(ctx context.Context, req LLMRequest, onChunk func(string)) (LLMResponse, error) {
+	resp, err := f.Generate(ctx, req)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+
+	const velicinaDijela = 16
+	runes := []rune(resp.Tekst)
+	for i := 0; i < len(runes); i += velicinaDijela {
+		kraj := min(i+velicinaDijela, len(runes))
+		onChunk(string(runes[i:kraj]))
+	}
+	return resp, nil
+}
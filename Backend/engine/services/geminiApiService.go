@@ -1,16 +1,12 @@
 package services
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"strings"
 	"time"
-
-	"github.com/ddobren/eduformacije/config"
-	"github.com/ddobren/eduformacije/models"
 )
 
 // geminiCandidatesResponse - pomaže nam parsirati Google Gemini odgovor
@@ -22,170 +18,139 @@ type geminiCandidatesResponse struct {
 			} `json:"parts"`
 		} `json:"content"`
 	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
 }
 
-// CallGeminiAPI - šalje prompt Google Gemini i vraća plain tekst
-func CallGeminiAPI(prompt string) (string, error) {
-	geminiAPIKey := config.GetEnv("GEMINI_API_KEY", "")
-	geminiEndpoint := config.GetEnv("GEMINI_ENDPOINT", "https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash:generateContent?key=")
+// geminiProvider - Google Gemini (generateContent REST API)
+type geminiProvider struct{}
 
-	payload := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{
-				"parts": []map[string]string{
-					{"text": prompt},
-				},
-			},
-		},
-	}
+func (geminiProvider) Naziv() string { return "gemini" }
 
-	bodyBytes, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("greška pri pripremi JSON payloada: %w", err)
-	}
+func (geminiProvider) Generate(ctx context.Context, req LLMRequest) (LLMResponse, error) {
+	return CallGeminiAPI(ctx, req.Prompt, req.Shema)
+}
+
+func (geminiProvider) GenerateStream(ctx context.Context, req LLMRequest, onChunk func(string)) (LLMResponse, error) {
+	return CallGeminiStreamAPI(ctx, req.Prompt, req.Shema, onChunk)
+}
 
-	req, err := http.NewRequest("POST", geminiEndpoint+geminiAPIKey, bytes.NewBuffer(bodyBytes))
+// CallGeminiAPI - šalje prompt Google Gemini i vraća tekst i potrošnju tokena.
+// Ako je zadana shema, uključuje JSON način (responseMimeType + responseSchema).
+func CallGeminiAPI(ctx context.Context, prompt string, shema *JSONShema) (LLMResponse, error) {
+	bodyBytes, err := geminiPayload(prompt, shema)
 	if err != nil {
-		return "", fmt.Errorf("greška pri kreiranju requesta: %w", err)
+		return LLMResponse{}, err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := posaljiGemini(ctx, "generateContent", bodyBytes, 30*time.Second)
 	if err != nil {
-		return "", fmt.Errorf("greška pri slanju zahteva Gemini API-ju: %w", err)
+		return LLMResponse{}, err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("greška pri čitanju odgovora Gemini API-ja: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("gemini API error, status %d: %s", resp.StatusCode, string(respBody))
+		return LLMResponse{}, fmt.Errorf("greška pri čitanju odgovora Gemini API-ja: %w", err)
 	}
 
 	var gResp geminiCandidatesResponse
 	if err := json.Unmarshal(respBody, &gResp); err != nil {
-		return "", fmt.Errorf("greška pri parsiranju odgovora Gemini API-ja: %w", err)
+		return LLMResponse{}, fmt.Errorf("greška pri parsiranju odgovora Gemini API-ja: %w", err)
 	}
 
 	if len(gResp.Candidates) == 0 || len(gResp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("nema candidates/parts u odgovoru AI-ja")
+		return LLMResponse{}, fmt.Errorf("nema candidates/parts u odgovoru AI-ja")
 	}
 
+	return LLMResponse{Tekst: gResp.tekst(), Potrosnja: gResp.potrosnja()}, nil
+}
+
+// tekst - spaja tekst svih dijelova svih kandidata
+func (g *geminiCandidatesResponse) tekst() string {
 	var fullText strings.Builder
-	for _, candidate := range gResp.Candidates {
+	for _, candidate := range g.Candidates {
 		for _, part := range candidate.Content.Parts {
 			fullText.WriteString(part.Text)
 		}
 	}
-
-	return fullText.String(), nil
+	return fullText.String()
 }
 
-// extractTextBetweenTripleBackticks - dohvaća SAMO sadržaj
-// između prve linije s ``` i sljedeće linije s ```.
-func extractTextBetweenTripleBackticks(s string) string {
-	lines := strings.Split(s, "\n")
-	var result []string
-
-	foundOpening := false
-	for _, line := range lines {
-		trim := strings.TrimSpace(line)
-
-		// Ako linija počinje s ``` i nismo u "otvorenom" stanju
-		if strings.HasPrefix(trim, "```") && !foundOpening {
-			foundOpening = true
-			continue
-		}
-		// Ako smo unutar backtick-bloka i naiđemo opet na ```
-		if foundOpening && strings.HasPrefix(trim, "```") {
-			break
-		}
-		if foundOpening {
-			result = append(result, line)
-		}
-	}
-	if len(result) == 0 {
-		return ""
+// potrosnja - tokeni iz usageMetadata (u streamu ih šalje zadnji dio)
+func (g *geminiCandidatesResponse) potrosnja() TokenPotrosnja {
+	return TokenPotrosnja{
+		Ulaz:   g.UsageMetadata.PromptTokenCount,
+		Izlaz:  g.UsageMetadata.CandidatesTokenCount,
+		Ukupno: g.UsageMetadata.TotalTokenCount,
 	}
-	return strings.Join(result, "\n")
 }
 
-// parseOrFallback - pokuša parse-ati cleanJSON, inače fallback na fullText
-func parseOrFallback(cleanJSON string, fullText string, aiResp interface{}) error {
-	// 1) Ako cleanJSON nije prazan, probamo njega
-	if cleanJSON != "" {
-		if err := json.Unmarshal([]byte(cleanJSON), aiResp); err == nil {
-			// Uspjelo
-			return nil
+// geminiPayload - tijelo zahtjeva za generateContent i streamGenerateContent
+func geminiPayload(prompt string, shema *JSONShema) ([]byte, error) {
+	payload := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"parts": []map[string]string{
+					{"text": prompt},
+				},
+			},
+		},
+	}
+
+	if shema != nil {
+		payload["generationConfig"] = map[string]interface{}{
+			"responseMimeType": "application/json",
+			"responseSchema":   shema,
 		}
-		// inače nastavljamo fallback
 	}
 
-	// 2) Pokušamo parse cijeli fullText
-	return json.Unmarshal([]byte(fullText), aiResp)
+	bodyBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("greška pri pripremi JSON payloada: %w", err)
+	}
+	return bodyBytes, nil
 }
 
-// GetRecommendations - glavni ulaz: prima interese, listu (SkolaProgramRokId, program)
-func GetRecommendations(interesi string, inputPrograms []models.ProgramWithID) (string, []models.ProgramWithID, error) {
-	// 1) JSON ulaznih programa
-	programsJSON, err := json.Marshal(inputPrograms)
+// CallGeminiStreamAPI - kao CallGeminiAPI, ali koristi streamGenerateContent (SSE)
+// i za svaki primljeni dio teksta poziva onChunk. Vraća cijeli tekst.
+func CallGeminiStreamAPI(ctx context.Context, prompt string, shema *JSONShema, onChunk func(string)) (LLMResponse, error) {
+	bodyBytes, err := geminiPayload(prompt, shema)
 	if err != nil {
-		return "", nil, fmt.Errorf("marshaling error: %w", err)
+		return LLMResponse{}, err
 	}
 
-	// 2) Sastavimo prompt
-	prompt := fmt.Sprintf(`
-	Ovo je popis školskih programa (s pripadajućim SkolaProgramRokId) u JSON formatu:
-	%s
-	
-	Interesi korisnika su: "%s".
-	
-	Pravila:
-	1. Uvijek vrati valjani JSON (bez trostrukih backtickova):
-	{
-	  "objasnjenje": "...",
-	  "programi": [
-		{ "SkolaProgramRokId":"...", "program":"..." },
-		...
-	  ]
-	}
-	2. "objasnjenje" uvijek treba sadržavati kratki, prijateljski tekst na hrvatskom (obraćaš se korisniku sa 'ti', možeš dodati emoji). 
-	   Nikad ne smije biti prazan ni null.
-	3. "programi" treba sadržavati barem jedan program iz popisa koji je povezan s interesima korisnika, a ako ništa ne podudara, predloži programe koji su najbliži interesima korisnika.
-	   Ako korisnikovi interesi nisu u direktnoj vezi s obrazovanjem, predloži popularne smjerove (npr. umjetnost, tehnologija, dizajn) koji su popularni među učenicima.
-	4. Uvijek ponudi barem nekoliko predloženih programa, čak i kad se čini da interesi nisu usko povezani s obrazovnim smjerovima.
-	   Nemoj duplicirati programe. Ako ništa ne odgovara, predloži nešto povezano i pozitivno.
-	5. Nikada nemoj reći da "nema programa". Umjesto toga, predloži nekoliko smjerova koji mogu biti korisni na temelju interesa korisnika.
-	6. Ne spominji ograničenja popisa programa i bilo što u tom kontekstu. Ovaj popis je potpun, no programi mogu biti šire povezani s interesima korisnika.
-	7. Ne dodaj nikakve dodatne ključeve ni tekst izvan zadanog JSON-a.
-	
-	Hvala!`, string(programsJSON), interesi)
-	
-
-	// 3) Zovemo AI
-	fullText, err := CallGeminiAPI(prompt)
+	resp, err := posaljiGemini(ctx, "streamGenerateContent", bodyBytes, 60*time.Second)
 	if err != nil {
-		return "", nil, fmt.Errorf("AI API error: %w", err)
+		return LLMResponse{}, err
 	}
+	defer resp.Body.Close()
 
-	// 4) Pokušamo izvući samo JSON između ``` ... ```
-	extracted := extractTextBetweenTripleBackticks(fullText)
-
-	// 5) Parsiranje
-	var aiResponse struct {
-		Objasnjenje string                 `json:"objasnjenje"`
-		Programi    []models.ProgramWithID `json:"programi"`
-	}
-	if err := parseOrFallback(extracted, fullText, &aiResponse); err != nil {
-		return "", nil, fmt.Errorf(
-			"nije došao valjani JSON od AI-ja:\n---\n%s\n---\nparse err: %w",
-			fullText, err,
-		)
+	var fullText strings.Builder
+	var potrosnja TokenPotrosnja
+	err = citajSSE(resp.Body, func(data string) error {
+		var gResp geminiCandidatesResponse
+		if err := json.Unmarshal([]byte(data), &gResp); err != nil {
+			return fmt.Errorf("greška pri parsiranju odgovora Gemini API-ja: %w", err)
+		}
+		if gResp.UsageMetadata.TotalTokenCount > 0 {
+			potrosnja = gResp.potrosnja()
+		}
+		if chunk := gResp.tekst(); chunk != "" {
+			fullText.WriteString(chunk)
+			onChunk(chunk)
+		}
+		return nil
+	})
+	if err != nil {
+		return LLMResponse{}, err
 	}
 
-	return aiResponse.Objasnjenje, aiResponse.Programi, nil
+	if fullText.Len() == 0 {
+		return LLMResponse{}, fmt.Errorf("nema candidates/parts u odgovoru AI-ja")
+	}
+	return LLMResponse{Tekst: fullText.String(), Potrosnja: potrosnja}, nil
 }
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ddobren/eduformacije/config"
+)
+
+// Gemini konfiguracija:
+//
+//	GEMINI_API_KEYS  - jedan ili više ključeva odvojenih zarezom (rotiraju se kad ključ potroši kvotu)
+//	GEMINI_API_KEY   - jedan ključ, ako GEMINI_API_KEYS nije postavljen
+//	GEMINI_MODEL     - naziv modela (default gemini-2.0-flash)
+//	GEMINI_BASE_URL  - osnovni URL API-ja (default https://generativelanguage.googleapis.com/v1beta)
+//
+// Ključ se šalje u x-goog-api-key zaglavlju, nikad u URL-u.
+const (
+	zadaniGeminiModel   = "gemini-2.0-flash"
+	zadaniGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+	// geminiPauzaKljuca - koliko se ključ preskače nakon 429 ako Gemini ne pošalje Retry-After
+	geminiPauzaKljuca = time.Minute
+)
+
+var stariEndpointOnce sync.Once
+
+// geminiURL - URL metode (generateContent, streamGenerateContent) za model iz konfiguracije
+func geminiURL(metoda string) string {
+	// Stari GEMINI_ENDPOINT (puni URL s "?key=" na kraju) i dalje radi, ali bez ključa u URL-u
+	if endpoint := config.GetEnv("GEMINI_ENDPOINT", ""); endpoint != "" {
+		stariEndpointOnce.Do(func() {
+			log.Println("GEMINI_ENDPOINT je zastario, koristi GEMINI_MODEL i GEMINI_BASE_URL")
+		})
+		endpoint, _, _ = strings.Cut(endpoint, "?")
+		endpoint = strings.Replace(endpoint, ":generateContent", ":"+metoda, 1)
+		if metoda == "streamGenerateContent" {
+			endpoint += "?alt=sse"
+		}
+		return endpoint
+	}
+
+	baseURL := strings.TrimRight(config.GetEnv("GEMINI_BASE_URL", zadaniGeminiBaseURL), "/")
+	model := strings.TrimPrefix(config.GetEnv("GEMINI_MODEL", zadaniGeminiModel), "models/")
+	url := baseURL + "/models/" + model + ":" + metoda
+	if metoda == "streamGenerateContent" {
+		url += "?alt=sse"
+	}
+	return url
+}
+
+// geminiAPIKljucevi - ključevi iz GEMINI_API_KEYS ili GEMINI_API_KEY
+func geminiAPIKljucevi() []string {
+	var kljucevi []string
+	for _, k := range strings.Split(config.GetEnv("GEMINI_API_KEYS", config.GetEnv("GEMINI_API_KEY", "")), ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			kljucevi = append(kljucevi, k)
+		}
+	}
+	return kljucevi
+}
+
+// geminiRotacija - koji ključ je trenutno aktivan i koji su privremeno iscrpljeni
+type geminiRotacija struct {
+	mu          sync.Mutex
+	trenutni    int
+	iscrpljenDo map[string]time.Time
+}
+
+var geminiKljucevi = &geminiRotacija{iscrpljenDo: make(map[string]time.Time)}
+
+// odaberi - trenutni ključ ako nije iscrpljen, inače prvi sljedeći koji nije; -1 ako su svi iscrpljeni
+func (r *geminiRotacija) odaberi(kljucevi []string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sada := time.Now()
+	for i := 0; i < len(kljucevi); i++ {
+		idx := (r.trenutni + i) % len(kljucevi)
+		if sada.After(r.iscrpljenDo[kljucevi[idx]]) {
+			r.trenutni = idx
+			return idx
+		}
+	}
+	return -1
+}
+
+// iscrpljen - ključ je potrošio kvotu; sljedeći pozivi idu na idući ključ
+func (r *geminiRotacija) iscrpljen(kljuc string, pauza time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.iscrpljenDo[kljuc] = time.Now().Add(pauza)
+	r.trenutni++
+}
+
+// doSljedeceg - koliko još treba čekati da prvi iscrpljeni ključ ponovno bude dostupan
+func (r *geminiRotacija) doSljedeceg(kljucevi []string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var najranije time.Time
+	for _, k := range kljucevi {
+		if t := r.iscrpljenDo[k]; najranije.IsZero() || t.Before(najranije) {
+			najranije = t
+		}
+	}
+	return time.Until(najranije)
+}
+
+// posaljiGemini - POST na zadanu metodu modela. Na 429 označava ključ iscrpljenim i odmah
+// pokušava sljedeći; vraća odgovor sa statusom 200 ili *UpstreamError.
+func posaljiGemini(ctx context.Context, metoda string, body []byte, timeout time.Duration) (*http.Response, error) {
+	kljucevi := geminiAPIKljucevi()
+	if len(kljucevi) == 0 {
+		return nil, fmt.Errorf("GEMINI_API_KEY nije postavljen")
+	}
+
+	client := &http.Client{Timeout: timeout}
+	var zadnjaGreska *UpstreamError
+	for range kljucevi {
+		idx := geminiKljucevi.odaberi(kljucevi)
+		if idx < 0 {
+			break
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", geminiURL(metoda), bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("greška pri kreiranju requesta: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-goog-api-key", kljucevi[idx])
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("greška pri slanju zahtjeva Gemini API-ju: %w", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		zadnjaGreska = novaUpstreamGreska("gemini", resp, respBody)
+		if resp.StatusCode != http.StatusTooManyRequests {
+			return nil, zadnjaGreska
+		}
+
+		pauza := zadnjaGreska.RetryAfter
+		if pauza <= 0 {
+			pauza = geminiPauzaKljuca
+		}
+		geminiKljucevi.iscrpljen(kljucevi[idx], pauza)
+		if len(kljucevi) > 1 {
+			log.Printf("Gemini ključ #%d je potrošio kvotu, prelazim na sljedeći", idx+1)
+		}
+	}
+
+	if zadnjaGreska == nil {
+		// Svi ključevi su već označeni iscrpljenima; vraćamo 429 da se aktivira čekanje i breaker
+		zadnjaGreska = &UpstreamError{
+			Provider:   "gemini",
+			Status:     http.StatusTooManyRequests,
+			RetryAfter: geminiKljucevi.doSljedeceg(kljucevi),
+		}
+	}
+	return nil, zadnjaGreska
+}
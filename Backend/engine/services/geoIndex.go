@@ -0,0 +1,112 @@
+package services
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ddobren/eduformacije/models"
+)
+
+const (
+	// Veličina ćelije mreže u stupnjevima (~11 km po geografskoj širini)
+	geoVelicinaCelije = 0.1
+	zemljaRadijusKm   = 6371.0
+)
+
+type geoCelija struct {
+	lat, lng int
+}
+
+func celijaZa(lat, lng float64) geoCelija {
+	return geoCelija{
+		lat: int(math.Floor(lat / geoVelicinaCelije)),
+		lng: int(math.Floor(lng / geoVelicinaCelije)),
+	}
+}
+
+// udaljenostKm - haversine udaljenost između dvije točke
+func udaljenostKm(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * zemljaRadijusKm * math.Asin(math.Sqrt(a))
+}
+
+// buildGeoIndex - raspoređuje programe s koordinatama u ćelije mreže
+func buildGeoIndex(skole []models.Skola) map[geoCelija][]int {
+	celije := make(map[geoCelija][]int)
+	for i, s := range skole {
+		if s.Lat == nil || s.Lng == nil {
+			continue
+		}
+		c := celijaZa(*s.Lat, *s.Lng)
+		celije[c] = append(celije[c], i)
+	}
+	return celije
+}
+
+// uOkviru - pozicije programa unutar pravokutnika (po ćelijama pa precizno)
+func (idx *SkoleIndex) uOkviru(minLat, minLng, maxLat, maxLng float64) []int {
+	od := celijaZa(minLat, minLng)
+	do := celijaZa(maxLat, maxLng)
+
+	unutra := func(s *models.Skola) bool {
+		return *s.Lat >= minLat && *s.Lat <= maxLat && *s.Lng >= minLng && *s.Lng <= maxLng
+	}
+
+	var result []int
+
+	// Za vrlo velike okvire je brže proći kroz sve programe nego kroz ćelije
+	if (do.lat-od.lat+1)*(do.lng-od.lng+1) > len(idx.Skole) {
+		for i := range idx.Skole {
+			s := &idx.Skole[i]
+			if s.Lat != nil && s.Lng != nil && unutra(s) {
+				result = append(result, i)
+			}
+		}
+		return result
+	}
+
+	for la := od.lat; la <= do.lat; la++ {
+		for ln := od.lng; ln <= do.lng; ln++ {
+			for _, i := range idx.geoCelije[geoCelija{la, ln}] {
+				if unutra(&idx.Skole[i]) {
+					result = append(result, i)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// UOkviru - programi unutar pravokutnika (npr. vidljivi dio karte)
+func (idx *SkoleIndex) UOkviru(minLat, minLng, maxLat, maxLng float64) []models.Skola {
+	pozicije := idx.uOkviru(minLat, minLng, maxLat, maxLng)
+	result := make([]models.Skola, 0, len(pozicije))
+	for _, i := range pozicije {
+		result = append(result, idx.Skole[i])
+	}
+	return result
+}
+
+// Blizu - programi unutar radiusKm od točke, sortirani po udaljenosti
+func (idx *SkoleIndex) Blizu(lat, lng, radiusKm float64) []models.SkolaUdaljenost {
+	// Okvir oko kružnice; širina stupnja geografske dužine ovisi o geografskoj širini
+	dLat := radiusKm / 111.0
+	dLng := radiusKm / (111.0 * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+
+	var result []models.SkolaUdaljenost
+	for _, i := range idx.uOkviru(lat-dLat, lng-dLng, lat+dLat, lng+dLng) {
+		s := idx.Skole[i]
+		d := udaljenostKm(lat, lng, *s.Lat, *s.Lng)
+		if d <= radiusKm {
+			result = append(result, models.SkolaUdaljenost{Skola: s, UdaljenostKm: math.Round(d*100) / 100})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].UdaljenostKm < result[j].UdaljenostKm
+	})
+	return result
+}
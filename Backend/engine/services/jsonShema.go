@@ -0,0 +1,99 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tipovi sheme (nazivi kakve očekuje Gemini responseSchema)
+const (
+	ShemaObject  = "OBJECT"
+	ShemaArray   = "ARRAY"
+	ShemaString  = "STRING"
+	ShemaNumber  = "NUMBER"
+	ShemaInteger = "INTEGER"
+	ShemaBoolean = "BOOLEAN"
+)
+
+// JSONShema - podskup OpenAPI sheme: šalje se modelu (JSON mode) i koristi za provjeru odgovora
+type JSONShema struct {
+	Type        string                `json:"type"`
+	Description string                `json:"description,omitempty"`
+	Properties  map[string]*JSONShema `json:"properties,omitempty"`
+	Required    []string              `json:"required,omitempty"`
+	Items       *JSONShema            `json:"items,omitempty"`
+	MinItems    *int                  `json:"minItems,omitempty"`
+
+	// NePrazno - string ne smije biti prazan (provjerava se samo na našoj strani)
+	NePrazno bool `json:"-"`
+}
+
+// Validiraj - provjerava vrijednost dobivenu iz json.Unmarshal u interface{}
+func (s *JSONShema) Validiraj(v interface{}) error {
+	return s.validiraj(v, "$")
+}
+
+func (s *JSONShema) validiraj(v interface{}, putanja string) error {
+	switch s.Type {
+	case ShemaObject:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: očekivan objekt", putanja)
+		}
+		for _, kljuc := range s.Required {
+			if val, postoji := obj[kljuc]; !postoji || val == nil {
+				return fmt.Errorf("%s: nedostaje obavezno polje %q", putanja, kljuc)
+			}
+		}
+		for kljuc, podShema := range s.Properties {
+			val, postoji := obj[kljuc]
+			if !postoji || val == nil {
+				continue
+			}
+			if err := podShema.validiraj(val, putanja+"."+kljuc); err != nil {
+				return err
+			}
+		}
+
+	case ShemaArray:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: očekivana lista", putanja)
+		}
+		if s.MinItems != nil && len(arr) < *s.MinItems {
+			return fmt.Errorf("%s: lista mora imati barem %d elemenata", putanja, *s.MinItems)
+		}
+		if s.Items != nil {
+			for i, el := range arr {
+				if err := s.Items.validiraj(el, fmt.Sprintf("%s[%d]", putanja, i)); err != nil {
+					return err
+				}
+			}
+		}
+
+	case ShemaString:
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: očekivan string", putanja)
+		}
+		if s.NePrazno && strings.TrimSpace(str) == "" {
+			return fmt.Errorf("%s: ne smije biti prazno", putanja)
+		}
+
+	case ShemaNumber, ShemaInteger:
+		n, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("%s: očekivan broj", putanja)
+		}
+		if s.Type == ShemaInteger && n != float64(int64(n)) {
+			return fmt.Errorf("%s: očekivan cijeli broj", putanja)
+		}
+
+	case ShemaBoolean:
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: očekivan boolean", putanja)
+		}
+	}
+
+	return nil
+}
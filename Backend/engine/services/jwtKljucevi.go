@@ -0,0 +1,272 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ddobren/eduformacije/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Asimetrični ključevi za potpis tokena (RS256 ili EdDSA) iz direktorija JWT_KLJUCEVI_DIR:
+//
+//	<kid>.pem      - privatni ključ (PKCS#8, ili PKCS#1 za RSA); koristi se za potpis i provjeru
+//	<kid>.pub.pem  - samo javni ključ; tokeni potpisani njime i dalje vrijede (npr. stari ključ nakon rotacije)
+//
+// Potpisuje se ključem JWT_AKTIVNI_KID (ako nije zadan, abecedno zadnjim privatnim ključem).
+// Rotacija: dodaj novi ključ, prebaci JWT_AKTIVNI_KID, a stari ključ zamijeni .pub.pem datotekom
+// dok ne isteknu svi tokeni potpisani njime. Bez ključeva se koristi HS256 s API_SECRET, koji tada
+// mora imati barem minDuljinaTajne znakova. Kad su asimetrični ključevi učitani, HS256 tokeni se
+// odbijaju, osim ako je JWT_HMAC_DOZVOLJEN=true (prijelazno razdoblje dok ne isteknu stari tokeni).
+
+// jwtKljuc - jedan učitani ključ
+type jwtKljuc struct {
+	kid      string
+	metoda   jwt.SigningMethod
+	privatni crypto.Signer // nil za ključeve samo za provjeru
+	javni    crypto.PublicKey
+}
+
+var (
+	jwtKljuceviMu sync.RWMutex
+	jwtKljucevi   = map[string]*jwtKljuc{}
+	jwtAktivni    *jwtKljuc
+)
+
+// ErrNepoznatKid - token je potpisan ključem koji nije (ili više nije) učitan
+var ErrNepoznatKid = errors.New("nepoznat kid")
+
+// parsePEM - prvi PEM blok datoteke
+func parsePEM(putanja string) (*pem.Block, error) {
+	data, err := os.ReadFile(putanja)
+	if err != nil {
+		return nil, err
+	}
+	blok, _ := pem.Decode(data)
+	if blok == nil {
+		return nil, fmt.Errorf("%s nije PEM datoteka", putanja)
+	}
+	return blok, nil
+}
+
+// metodaZaKljuc - RS256 za RSA, EdDSA za Ed25519
+func metodaZaKljuc(javni crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch k := javni.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA ključ mora imati barem 2048 bitova")
+		}
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("nepodržana vrsta ključa %T (podržani su RSA i Ed25519)", javni)
+	}
+}
+
+func ucitajPrivatniKljuc(putanja string) (crypto.Signer, error) {
+	blok, err := parsePEM(putanja)
+	if err != nil {
+		return nil, err
+	}
+	if blok.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(blok.Bytes)
+	}
+	kljuc, err := x509.ParsePKCS8PrivateKey(blok.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := kljuc.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("nepodržana vrsta ključa %T", kljuc)
+	}
+	return signer, nil
+}
+
+func ucitajJavniKljuc(putanja string) (crypto.PublicKey, error) {
+	blok, err := parsePEM(putanja)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParsePKIXPublicKey(blok.Bytes)
+}
+
+// UcitajJWTKljuceve - (ponovno) učitava ključeve iz JWT_KLJUCEVI_DIR. Ako učitavanje ne uspije,
+// ostaju stari ključevi. Poziva se pri pokretanju i na SIGHUP.
+func UcitajJWTKljuceve() error {
+	dir := config.GetEnv("JWT_KLJUCEVI_DIR", "jwt-kljucevi")
+
+	datoteke, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	ucitani := make(map[string]*jwtKljuc)
+	for _, datoteka := range datoteke {
+		naziv := filepath.Base(datoteka)
+		k := &jwtKljuc{}
+
+		if kid, ok := strings.CutSuffix(naziv, ".pub.pem"); ok {
+			k.kid = kid
+			if k.javni, err = ucitajJavniKljuc(datoteka); err != nil {
+				return fmt.Errorf("JWT ključ %s: %w", naziv, err)
+			}
+		} else {
+			k.kid = strings.TrimSuffix(naziv, ".pem")
+			if k.privatni, err = ucitajPrivatniKljuc(datoteka); err != nil {
+				return fmt.Errorf("JWT ključ %s: %w", naziv, err)
+			}
+			k.javni = k.privatni.Public()
+		}
+
+		if k.metoda, err = metodaZaKljuc(k.javni); err != nil {
+			return fmt.Errorf("JWT ključ %s: %w", naziv, err)
+		}
+		if _, postoji := ucitani[k.kid]; postoji {
+			return fmt.Errorf("JWT ključ %s: kid %s postoji i kao privatni i kao javni ključ", naziv, k.kid)
+		}
+		ucitani[k.kid] = k
+	}
+
+	var aktivni *jwtKljuc
+	if len(ucitani) == 0 {
+		if _, err := apiTajna(); err != nil {
+			return fmt.Errorf("nema JWT ključeva u %s, a HS256 potpis nije moguć: %w", dir, err)
+		}
+	} else {
+		kid := config.GetEnv("JWT_AKTIVNI_KID", "")
+		if kid == "" {
+			var privatni []string
+			for id, k := range ucitani {
+				if k.privatni != nil {
+					privatni = append(privatni, id)
+				}
+			}
+			sort.Strings(privatni)
+			if len(privatni) > 0 {
+				kid = privatni[len(privatni)-1]
+			}
+		}
+		aktivni = ucitani[kid]
+		if aktivni == nil || aktivni.privatni == nil {
+			return fmt.Errorf("JWT_AKTIVNI_KID %q nema privatni ključ u %s", kid, dir)
+		}
+	}
+
+	jwtKljuceviMu.Lock()
+	jwtKljucevi = ucitani
+	jwtAktivni = aktivni
+	jwtKljuceviMu.Unlock()
+
+	if aktivni == nil {
+		log.Printf("Nema JWT ključeva u %s, tokeni se potpisuju s HS256", dir)
+	} else {
+		log.Printf("Učitano %d JWT ključeva iz %s, aktivni kid %s (%s)", len(ucitani), dir, aktivni.kid, aktivni.metoda.Alg())
+	}
+	return nil
+}
+
+// hmacDozvoljen - prihvaćaju li se HS256 tokeni (uvijek dok nema asimetričnih ključeva, a uz njih
+// samo ako je JWT_HMAC_DOZVOLJEN=true)
+func hmacDozvoljen() bool {
+	jwtKljuceviMu.RLock()
+	asimetricni := jwtAktivni != nil
+	jwtKljuceviMu.RUnlock()
+	if !asimetricni {
+		return true
+	}
+	dozvoljen, err := strconv.ParseBool(config.GetEnv("JWT_HMAC_DOZVOLJEN", "false"))
+	return err == nil && dozvoljen
+}
+
+// potpisiToken - potpisuje aktivnim ključem (s kid u zaglavlju) ili s HS256 i API_SECRET
+func potpisiToken(claims jwt.MapClaims) (string, error) {
+	jwtKljuceviMu.RLock()
+	aktivni := jwtAktivni
+	jwtKljuceviMu.RUnlock()
+
+	if aktivni == nil {
+		tajna, err := apiTajna()
+		if err != nil {
+			return "", err
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(tajna)
+	}
+
+	token := jwt.NewWithClaims(aktivni.metoda, claims)
+	token.Header["kid"] = aktivni.kid
+	return token.SignedString(aktivni.privatni)
+}
+
+// KljucZaProvjeru - jwt.Keyfunc: ključ prema algoritmu i kid zaglavlju tokena
+func KljucZaProvjeru(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if !hmacDozvoljen() {
+			return nil, fmt.Errorf("HS256 tokeni više nisu dozvoljeni")
+		}
+		return apiTajna()
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	jwtKljuceviMu.RLock()
+	k := jwtKljucevi[kid]
+	jwtKljuceviMu.RUnlock()
+	if k == nil {
+		return nil, fmt.Errorf("%w: %q", ErrNepoznatKid, kid)
+	}
+	if token.Method.Alg() != k.metoda.Alg() {
+		return nil, fmt.Errorf("algoritam %s ne odgovara ključu %s", token.Method.Alg(), kid)
+	}
+	return k.javni, nil
+}
+
+// JWK - javni ključ u JWK formatu (RFC 7517, 8037)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS - svi učitani javni ključevi, uključujući one samo za provjeru
+func JWKS() []JWK {
+	jwtKljuceviMu.RLock()
+	defer jwtKljuceviMu.RUnlock()
+
+	kljucevi := make([]JWK, 0, len(jwtKljucevi))
+	for _, k := range jwtKljucevi {
+		jwk := JWK{Kid: k.kid, Use: "sig", Alg: k.metoda.Alg()}
+		switch javni := k.javni.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(javni.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(javni.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(javni)
+		}
+		kljucevi = append(kljucevi, jwk)
+	}
+	sort.Slice(kljucevi, func(i, j int) bool { return kljucevi[i].Kid < kljucevi[j].Kid })
+	return kljucevi
+}
@@ -1,23 +1,73 @@
 package services
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
 	"time"
 
 	"github.com/ddobren/eduformacije/config"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// GenerateJWT - generira novi JWT token za autentifikaciju
-// Ova simple autentifikacija je urađena samo za preventiranje od abuse-a, nije bila nužna
-func GenerateJWT() (string, error) {
-	secretKey := config.GetEnv("API_SECRET", "")
+// Zadane vrijednosti claimova; mogu se promijeniti kroz AUTH_ISSUER, AUTH_AUDIENCE i AUTH_TOKEN_TTL
+const (
+	zadaniIssuer   = "eduformacije"
+	zadaniAudience = "eduformacije-api"
+	zadaniTokenTTL = 15 * time.Minute
+)
+
+// TokenIssuer - vrijednost "iss" claima
+func TokenIssuer() string {
+	return config.GetEnv("AUTH_ISSUER", zadaniIssuer)
+}
+
+// TokenAudience - vrijednost "aud" claima
+func TokenAudience() string {
+	return config.GetEnv("AUTH_AUDIENCE", zadaniAudience)
+}
+
+// tokenTTL - trajanje pristupnog tokena
+func tokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(config.GetEnv("AUTH_TOKEN_TTL", zadaniTokenTTL.String()))
+	if err != nil || ttl <= 0 {
+		return zadaniTokenTTL
+	}
+	return ttl
+}
+
+// GenerateJWT - izdaje kratkotrajni pristupni token za klijenta s odabranim scope-ovima.
+// Vraća token i trenutak isteka.
+func GenerateJWT(klijent APIKlijent, scope []string) (string, time.Time, error) {
+	return izdajToken(klijent, scope, tokenTTL())
+}
 
+// izdajToken - potpisuje token sa svim obaveznim claimovima (iss, aud, sub, scope, iat, exp, jti)
+// aktivnim ključem iz JWT_KLJUCEVI_DIR, odnosno s API_SECRET ako ključeva nema
+func izdajToken(klijent APIKlijent, scope []string, ttl time.Duration) (string, time.Time, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", time.Time{}, err
+	}
+
+	sada := time.Now()
+	istek := sada.Add(ttl)
 	claims := jwt.MapClaims{
-		"authorized": true,
-		"client":     "eduformacije-frontend",
-		"exp":        time.Now().Add(24 * time.Hour).Unix(), // Token vrijedi 24h
+		"iss":   TokenIssuer(),
+		"aud":   TokenAudience(),
+		"sub":   klijent.Id,
+		"scope": strings.Join(scope, " "),
+		"iat":   sada.Unix(),
+		"exp":   istek.Unix(),
+		"jti":   hex.EncodeToString(jti),
+	}
+	if klijent.Uloga != "" {
+		claims["role"] = klijent.Uloga
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secretKey))
+	signed, err := potpisiToken(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, istek, nil
 }
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ddobren/eduformacije/config"
+)
+
+// Otpornost poziva modela: ograničen broj istovremenih poziva, ponovni pokušaji s
+// eksponencijalnim čekanjem na 429/5xx i circuit breaker po provideru.
+//
+//	AI_TIMEOUT           - ukupno vrijeme za jedan poziv s ponovnim pokušajima (default 20s)
+//	AI_POKUSAJI          - najviše pokušaja po pozivu (default 3)
+//	AI_MAX_ISTOVREMENIH  - najviše istovremenih poziva modela (default 8)
+//	AI_CB_PRAG           - uzastopnih grešaka nakon kojih se circuit otvara (default 5)
+//	AI_CB_PAUZA          - koliko dugo je circuit otvoren prije probnog poziva (default 30s)
+
+// ErrAINedostupan - poziv modela nije ni pokušan jer je circuit otvoren ili su svi slotovi zauzeti
+var ErrAINedostupan = errors.New("AI servis je privremeno nedostupan")
+
+// UpstreamError - provider je vratio status različit od 200. Tijelo odgovora se samo
+// logira (skraćeno) jer može sadržavati interne detalje providera.
+type UpstreamError struct {
+	Provider   string
+	Status     int
+	RetryAfter time.Duration // iz Retry-After zaglavlja, 0 ako ga nema
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("%s API error, status %d", e.Provider, e.Status)
+}
+
+// ponovljiva - 429 i 5xx su privremene greške
+func (e *UpstreamError) ponovljiva() bool {
+	return e.Status == http.StatusTooManyRequests || e.Status >= 500
+}
+
+const maxLogTijela = 500
+
+// novaUpstreamGreska - logira skraćeno tijelo i vraća grešku bez njega
+func novaUpstreamGreska(provider string, resp *http.Response, tijelo []byte) *UpstreamError {
+	if len(tijelo) > maxLogTijela {
+		tijelo = tijelo[:maxLogTijela]
+	}
+	log.Printf("%s API status %d: %s", provider, resp.StatusCode, string(tijelo))
+	return &UpstreamError{
+		Provider:   provider,
+		Status:     resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter - Retry-After u sekundama ili kao HTTP datum
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+func envTrajanje(kljuc string, zadano time.Duration) time.Duration {
+	d, err := time.ParseDuration(config.GetEnv(kljuc, zadano.String()))
+	if err != nil || d <= 0 {
+		return zadano
+	}
+	return d
+}
+
+// Stanja circuit breakera
+const (
+	cbZatvoren    = "zatvoren"
+	cbOtvoren     = "otvoren"
+	cbPoluotvoren = "poluotvoren" // jedan probni poziv nakon pauze
+)
+
+// circuitBreaker - jednostavan breaker u memoriji procesa
+type circuitBreaker struct {
+	mu        sync.Mutex
+	provider  string
+	stanje    string
+	greske    int
+	otvorenDo time.Time
+}
+
+var (
+	breakeriMu sync.Mutex
+	breakeri   = make(map[string]*circuitBreaker)
+)
+
+func breakerZa(provider string) *circuitBreaker {
+	breakeriMu.Lock()
+	defer breakeriMu.Unlock()
+	cb, ok := breakeri[provider]
+	if !ok {
+		cb = &circuitBreaker{provider: provider, stanje: cbZatvoren}
+		breakeri[provider] = cb
+	}
+	return cb
+}
+
+// dozvoli - smije li se poziv pokušati; nakon pauze propušta jedan probni poziv
+func (cb *circuitBreaker) dozvoli() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.stanje {
+	case cbOtvoren:
+		if time.Now().Before(cb.otvorenDo) {
+			return false
+		}
+		cb.stanje = cbPoluotvoren
+		return true
+	case cbPoluotvoren:
+		// Probni poziv je već u tijeku
+		return false
+	default:
+		return true
+	}
+}
+
+func (cb *circuitBreaker) uspjeh() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.stanje != cbZatvoren {
+		log.Printf("AI circuit breaker (%s): zatvoren", cb.provider)
+	}
+	cb.stanje = cbZatvoren
+	cb.greske = 0
+}
+
+func (cb *circuitBreaker) neuspjeh() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.greske++
+	if cb.stanje == cbPoluotvoren || cb.greske >= envInt("AI_CB_PRAG", 5) {
+		pauza := envTrajanje("AI_CB_PAUZA", 30*time.Second)
+		if cb.stanje != cbOtvoren {
+			log.Printf("AI circuit breaker (%s): otvoren na %s nakon %d grešaka", cb.provider, pauza, cb.greske)
+		}
+		cb.stanje = cbOtvoren
+		cb.otvorenDo = time.Now().Add(pauza)
+	}
+}
+
+// odustani - probni poziv nije ni dovršen (npr. korisnik je prekinuo zahtjev); breaker se vraća u otvoreno stanje
+func (cb *circuitBreaker) odustani() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.stanje == cbPoluotvoren {
+		cb.stanje = cbOtvoren
+	}
+}
+
+// StanjeAIProvidera - trenutno stanje breakera po provideru (za status i admin)
+func StanjeAIProvidera() map[string]string {
+	breakeriMu.Lock()
+	defer breakeriMu.Unlock()
+	stanja := make(map[string]string, len(breakeri))
+	for naziv, cb := range breakeri {
+		cb.mu.Lock()
+		stanja[naziv] = cb.stanje
+		cb.mu.Unlock()
+	}
+	return stanja
+}
+
+// Slotovi za istovremene pozive; veličina se čita jednom pri prvom pozivu
+var (
+	aiSlotoviOnce sync.Once
+	aiSlotovi     chan struct{}
+)
+
+// maxCekanjeSlota - koliko zahtjev čeka slobodan slot prije nego odustane
+const maxCekanjeSlota = 2 * time.Second
+
+func zauzmiSlot(ctx context.Context) (func(), error) {
+	aiSlotoviOnce.Do(func() {
+		n := envInt("AI_MAX_ISTOVREMENIH", 8)
+		if n < 1 {
+			n = 1
+		}
+		aiSlotovi = make(chan struct{}, n)
+	})
+
+	timer := time.NewTimer(maxCekanjeSlota)
+	defer timer.Stop()
+	select {
+	case aiSlotovi <- struct{}{}:
+		return func() { <-aiSlotovi }, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: previše istovremenih poziva", ErrAINedostupan)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// cekanjePrijePokusaja - eksponencijalno čekanje s nasumičnim odstupanjem ili Retry-After ako ga je provider poslao
+func cekanjePrijePokusaja(pokusaj int, err error) time.Duration {
+	var upErr *UpstreamError
+	if errors.As(err, &upErr) && upErr.RetryAfter > 0 {
+		return upErr.RetryAfter
+	}
+	osnova := 500 * time.Millisecond << (pokusaj - 1)
+	if osnova > 8*time.Second {
+		osnova = 8 * time.Second
+	}
+	return osnova/2 + time.Duration(rand.Int63n(int64(osnova/2)+1))
+}
+
+// ponovljivaGreska - vrijedi li ponoviti poziv (mrežna greška, 429 ili 5xx)
+func ponovljivaGreska(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var upErr *UpstreamError
+	if errors.As(err, &upErr) {
+		return upErr.ponovljiva()
+	}
+	return true
+}
+
+// kvarProvidera - broji li se greška kao neuspjeh breakera: 429, 5xx, istek roka i mrežne
+// greške. Ostali statusi (400, 401, 403...) znače da provider odgovara, a problem je u zahtjevu ili ključu.
+func kvarProvidera(err error) bool {
+	var upErr *UpstreamError
+	if errors.As(err, &upErr) {
+		return upErr.ponovljiva()
+	}
+	return true
+}
+
+// pozoviOtporno - izvršava poziv kroz breaker i slot, uz ponovne pokušaje dok
+// je greška privremena. Pokušaji se ne ponavljaju ako je poziv već nešto poslao klijentu.
+func pozoviOtporno(ctx context.Context, provider string, poziv func(ctx context.Context) (LLMResponse, bool, error)) (LLMResponse, error) {
+	cb := breakerZa(provider)
+	if !cb.dozvoli() {
+		return LLMResponse{}, fmt.Errorf("%w: circuit breaker (%s) je otvoren", ErrAINedostupan, provider)
+	}
+
+	oslobodi, err := zauzmiSlot(ctx)
+	if err != nil {
+		cb.odustani()
+		return LLMResponse{}, err
+	}
+	defer oslobodi()
+
+	pokusaji := envInt("AI_POKUSAJI", 3)
+	if pokusaji < 1 {
+		pokusaji = 1
+	}
+
+	for pokusaj := 1; ; pokusaj++ {
+		resp, poslano, err := poziv(ctx)
+		if err == nil {
+			cb.uspjeh()
+			return resp, nil
+		}
+
+		// Prekid od strane klijenta nije greška providera
+		if errors.Is(ctx.Err(), context.Canceled) {
+			cb.odustani()
+			return LLMResponse{}, err
+		}
+
+		if poslano || pokusaj >= pokusaji || !ponovljivaGreska(ctx, err) {
+			if kvarProvidera(err) {
+				cb.neuspjeh()
+			} else {
+				cb.uspjeh()
+			}
+			return LLMResponse{}, err
+		}
+
+		cekanje := cekanjePrijePokusaja(pokusaj, err)
+		if rok, ok := ctx.Deadline(); ok && time.Now().Add(cekanje).After(rok) {
+			// Nema smisla čekati dulje od roka zahtjeva
+			cb.neuspjeh()
+			return LLMResponse{}, err
+		}
+		log.Printf("AI (%s) pokušaj %d/%d nije uspio (%v), ponavljam za %s", provider, pokusaj, pokusaji, err, cekanje.Round(time.Millisecond))
+
+		timer := time.NewTimer(cekanje)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			cb.odustani()
+			return LLMResponse{}, ctx.Err()
+		}
+	}
+}
+
+// otporniProvider - omotač koji svaki poziv providera vodi kroz pozoviOtporno
+type otporniProvider struct {
+	LLMProvider
+}
+
+func (p otporniProvider) Generate(ctx context.Context, req LLMRequest) (LLMResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, envTrajanje("AI_TIMEOUT", 20*time.Second))
+	defer cancel()
+	return pozoviOtporno(ctx, p.Naziv(), func(ctx context.Context) (LLMResponse, bool, error) {
+		resp, err := p.LLMProvider.Generate(ctx, req)
+		return resp, false, err
+	})
+}
+
+// otporniStreamProvider - kao otporniProvider, ali zadržava podršku za stream
+type otporniStreamProvider struct {
+	otporniProvider
+	stream LLMStreamProvider
+}
+
+func (p otporniStreamProvider) GenerateStream(ctx context.Context, req LLMRequest, onChunk func(string)) (LLMResponse, error) {
+	return pozoviOtporno(ctx, p.Naziv(), func(ctx context.Context) (LLMResponse, bool, error) {
+		poslano := false
+		resp, err := p.stream.GenerateStream(ctx, req, func(chunk string) {
+			poslano = true
+			onChunk(chunk)
+		})
+		return resp, poslano, err
+	})
+}
+
+// otporno - omata providera; stream provider ostaje stream provider
+func otporno(provider LLMProvider) LLMProvider {
+	if sp, ok := provider.(LLMStreamProvider); ok {
+		return otporniStreamProvider{otporniProvider: otporniProvider{provider}, stream: sp}
+	}
+	return otporniProvider{provider}
+}
+
+// AINedostupan - je li greška posljedica nedostupnosti modela (otvoren breaker, pun red,
+// istek vremena ili greška providera), za razliku od neispravnog odgovora modela
+func AINedostupan(err error) bool {
+	var upErr *UpstreamError
+	return errors.Is(err, ErrAINedostupan) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &upErr)
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/ddobren/eduformacije/config"
+)
+
+// LLMRequest - upit prema jezičnom modelu
+type LLMRequest struct {
+	Prompt string
+	// Shema - ako je zadana, provider traži JSON odgovor (Gemini responseSchema, OpenAI json_object)
+	Shema *JSONShema
+}
+
+// LLMResponse - odgovor jezičnog modela
+type LLMResponse struct {
+	Tekst     string
+	Potrosnja TokenPotrosnja
+}
+
+// TokenPotrosnja - broj tokena jednog poziva (Gemini usageMetadata, OpenAI usage)
+type TokenPotrosnja struct {
+	Ulaz   int
+	Izlaz  int
+	Ukupno int
+}
+
+// LLMProvider - jezični model koji na temelju prompta vraća tekst.
+// Implementacije: gemini, openai (bilo koji OpenAI-kompatibilni chat endpoint) i fake (za testiranje).
+type LLMProvider interface {
+	Naziv() string
+	Generate(ctx context.Context, req LLMRequest) (LLMResponse, error)
+}
+
+// LLMStreamProvider - provider koji može vraćati tekst postupno (npr. preko SSE)
+type LLMStreamProvider interface {
+	LLMProvider
+	GenerateStream(ctx context.Context, req LLMRequest, onChunk func(string)) (LLMResponse, error)
+}
+
+// citajSSE - čita Server-Sent Events tijelo i za svaki "data:" redak poziva obradi.
+// Završava na kraju tijela ili na "[DONE]" (OpenAI).
+func citajSSE(body io.Reader, obradi func(data string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+		if data == "[DONE]" {
+			return nil
+		}
+		if err := obradi(data); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("greška pri čitanju streama: %w", err)
+	}
+	return nil
+}
+
+// GetLLMProvider - vraća providera odabranog kroz LLM_PROVIDER (default: gemini).
+// Mrežni provideri su omotani circuit breakerom i ponovnim pokušajima (vidi llmOtpornost.go).
+func GetLLMProvider() (LLMProvider, error) {
+	naziv := strings.ToLower(strings.TrimSpace(config.GetEnv("LLM_PROVIDER", "gemini")))
+	switch naziv {
+	case "gemini":
+		return otporno(geminiProvider{}), nil
+	case "openai":
+		return otporno(openAIProvider{}), nil
+	case "fake":
+		return fakeLLMProvider{}, nil
+	default:
+		return nil, fmt.Errorf("nepoznat LLM_PROVIDER: %s", naziv)
+	}
+}
+
+// AIOdgovorError - model nije vratio JSON koji odgovara shemi ni nakon pokušaja popravka.
+// Namjerno ne sadrži sirovi tekst modela - on se samo logira.
+type AIOdgovorError struct {
+	Provider string
+	Pokusaji int
+	Razlog   error
+}
+
+func (e *AIOdgovorError) Error() string {
+	return fmt.Sprintf("AI (%s) nije vratio valjan JSON nakon %d pokušaja: %v", e.Provider, e.Pokusaji, e.Razlog)
+}
+
+func (e *AIOdgovorError) Unwrap() error { return e.Razlog }
+
+const maxDuljinaZaPopravak = 4000
+
+// dekodirajIValidiraj - parsira tekst modela (cijeli ili između ```), provjerava shemu i puni out
+func dekodirajIValidiraj(tekst string, shema *JSONShema, out interface{}) error {
+	var generic interface{}
+	if err := parseOrFallback(extractTextBetweenTripleBackticks(tekst), tekst, &generic); err != nil {
+		return fmt.Errorf("nevažeći JSON: %w", err)
+	}
+	if err := shema.Validiraj(generic); err != nil {
+		return err
+	}
+
+	// Ponovno kodiramo već provjerenu vrijednost da dobijemo tipiziranu strukturu
+	data, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// GenerirajJSON - poziva model u JSON načinu, provjerava odgovor prema shemi i po potrebi
+// traži popravak (AI_POPRAVAK_POKUSAJI, default 1). Ako ni popravak ne uspije, vraća *AIOdgovorError.
+func GenerirajJSON(ctx context.Context, provider LLMProvider, prompt string, shema *JSONShema, out interface{}) (LLMResponse, error) {
+	popravci, err := strconv.Atoi(config.GetEnv("AI_POPRAVAK_POKUSAJI", "1"))
+	if err != nil || popravci < 0 {
+		popravci = 1
+	}
+
+	trenutniPrompt := prompt
+	var zadnjaGreska error
+	for pokusaj := 1; pokusaj <= popravci+1; pokusaj++ {
+		resp, err := provider.Generate(ctx, LLMRequest{Prompt: trenutniPrompt, Shema: shema})
+		if err != nil {
+			return LLMResponse{}, fmt.Errorf("AI API error (%s): %w", provider.Naziv(), err)
+		}
+		// I neuspjeli pokušaji troše tokene
+		ZabiljeziPotrosnju(ctx, provider.Naziv(), resp.Potrosnja)
+
+		zadnjaGreska = dekodirajIValidiraj(resp.Tekst, shema, out)
+		if zadnjaGreska == nil {
+			return resp, nil
+		}
+
+		prethodni := resp.Tekst
+		if len(prethodni) > maxDuljinaZaPopravak {
+			prethodni = prethodni[:maxDuljinaZaPopravak]
+		}
+		log.Printf("AI (%s) pokušaj %d: nevažeći odgovor (%v); tekst: %q", provider.Naziv(), pokusaj, zadnjaGreska, prethodni)
+
+		trenutniPrompt = fmt.Sprintf(`%s
+
+Tvoj prethodni odgovor nije bio valjan: %v.
+Prethodni odgovor:
+%s
+
+Vrati ISKLJUČIVO ispravljeni JSON koji odgovara zadanoj shemi, bez ikakvog drugog teksta.`, prompt, zadnjaGreska, prethodni)
+	}
+
+	return LLMResponse{}, &AIOdgovorError{Provider: provider.Naziv(), Pokusaji: popravci + 1, Razlog: zadnjaGreska}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ddobren/eduformacije/database"
+	"github.com/redis/go-redis/v9"
+)
+
+// Način održavanja: zastavica u Redisu (vrijedi za sve instance). Dok je uključen, javne rute
+// vraćaju 503, a /api/v1/admin i izdavanje tokena rade normalno.
+const (
+	odrzavanjeKljuc = "admin:odrzavanje"
+
+	// Koliko dugo instanca pamti stanje prije ponovnog čitanja iz Redisa
+	odrzavanjeCacheTTL = 2 * time.Second
+)
+
+// Odrzavanje - stanje načina održavanja
+type Odrzavanje struct {
+	Ukljuceno bool      `json:"ukljuceno"`
+	Poruka    string    `json:"poruka,omitempty"`
+	Od        time.Time `json:"od,omitempty"`
+	Ukljucio  string    `json:"ukljucio,omitempty"`
+}
+
+var (
+	odrzavanjeMu        sync.Mutex
+	odrzavanjeStanje    Odrzavanje
+	odrzavanjeProcitano time.Time
+)
+
+// StanjeOdrzavanja - trenutno stanje (iz kratkog cachea u memoriji)
+func StanjeOdrzavanja(ctx context.Context) (Odrzavanje, error) {
+	odrzavanjeMu.Lock()
+	defer odrzavanjeMu.Unlock()
+	if time.Since(odrzavanjeProcitano) < odrzavanjeCacheTTL {
+		return odrzavanjeStanje, nil
+	}
+
+	var stanje Odrzavanje
+	data, err := database.GetRedisClient().Get(ctx, odrzavanjeKljuc).Bytes()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return odrzavanjeStanje, fmt.Errorf("greška pri čitanju načina održavanja: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &stanje); err != nil {
+			return odrzavanjeStanje, fmt.Errorf("neispravno stanje načina održavanja: %w", err)
+		}
+	}
+
+	odrzavanjeStanje = stanje
+	odrzavanjeProcitano = time.Now()
+	return stanje, nil
+}
+
+// PostaviOdrzavanje - uključuje ili isključuje način održavanja
+func PostaviOdrzavanje(ctx context.Context, ukljuceno bool, poruka, ukljucio string) (Odrzavanje, error) {
+	rdb := database.GetRedisClient()
+	stanje := Odrzavanje{}
+
+	if ukljuceno {
+		stanje = Odrzavanje{Ukljuceno: true, Poruka: poruka, Od: time.Now(), Ukljucio: ukljucio}
+		data, err := json.Marshal(stanje)
+		if err != nil {
+			return stanje, err
+		}
+		if err := rdb.Set(ctx, odrzavanjeKljuc, data, 0).Err(); err != nil {
+			return stanje, fmt.Errorf("greška pri uključivanju načina održavanja: %w", err)
+		}
+	} else if err := rdb.Del(ctx, odrzavanjeKljuc).Err(); err != nil {
+		return stanje, fmt.Errorf("greška pri isključivanju načina održavanja: %w", err)
+	}
+
+	odrzavanjeMu.Lock()
+	odrzavanjeStanje = stanje
+	odrzavanjeProcitano = time.Now()
+	odrzavanjeMu.Unlock()
+	return stanje, nil
+}
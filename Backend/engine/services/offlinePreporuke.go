@@ -0,0 +1,320 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ddobren/eduformacije/models"
+)
+
+const (
+	offlineBrojPrijedloga      = 10
+	offlineMaxIstogPrograma    = 3 // da jedan program iz više škola ne zauzme cijelu listu
+	offlineTezinaProgram       = 2.0
+	offlineTezinaKljucneRijeci = 1.5
+	offlineTezinaVrsta         = 1.0
+)
+
+// kljucneRijeciPrograma - dio naziva programa (normaliziran) -> interesi koje taj program pokriva.
+// Ručno održavana lista; riječi su bez dijakritika jer se uspoređuju nakon NormalizirajTekst.
+var kljucneRijeciPrograma = map[string][]string{
+	"racunal":    {"racunala", "kompjuteri", "programiranje", "kodiranje", "informatika", "tehnologija", "igrice", "internet"},
+	"informat":   {"racunala", "programiranje", "informatika", "tehnologija"},
+	"elektro":    {"elektronika", "elektrika", "struja", "roboti", "tehnologija"},
+	"mehatron":   {"roboti", "elektronika", "strojevi", "automatizacija"},
+	"strojar":    {"strojevi", "mehanika", "alati", "popravljanje"},
+	"auto":       {"auti", "automobili", "motori", "mehanika", "popravljanje"},
+	"kuhar":      {"kuhanje", "hrana", "kuhinja", "recepti"},
+	"slastic":    {"kolaci", "slatkisi", "pecenje", "hrana"},
+	"pekar":      {"pecenje", "kruh", "hrana"},
+	"konobar":    {"ugostiteljstvo", "ljudi", "posluzivanje", "turizam"},
+	"turist":     {"turizam", "putovanja", "jezici", "ljudi", "more"},
+	"hotel":      {"turizam", "putovanja", "ljudi", "organizacija"},
+	"ekonom":     {"novac", "biznis", "poduzetnistvo", "financije", "matematika"},
+	"komercijal": {"prodaja", "biznis", "trgovina", "ljudi", "novac"},
+	"prodav":     {"prodaja", "trgovina", "ljudi"},
+	"upravn":     {"ured", "organizacija", "pravo", "administracija"},
+	"administr":  {"ured", "organizacija", "administracija", "racunala"},
+	"gimnazij":   {"ucenje", "fakultet", "znanost", "opcenito"},
+	"jezicn":     {"jezici", "engleski", "njemacki", "talijanski", "putovanja"},
+	"klasicn":    {"latinski", "povijest", "jezici", "knjizevnost"},
+	"prirodoslo": {"znanost", "biologija", "kemija", "fizika", "priroda"},
+	"matemat":    {"matematika", "fizika", "logika", "racunala"},
+	"umjetn":     {"umjetnost", "crtanje", "slikanje", "kreativnost", "dizajn"},
+	"dizajn":     {"dizajn", "crtanje", "umjetnost", "kreativnost", "moda"},
+	"grafic":     {"dizajn", "crtanje", "racunala", "tisak", "kreativnost"},
+	"glazb":      {"glazba", "muzika", "pjevanje", "sviranje", "instrument"},
+	"plesn":      {"ples", "plesanje", "balet", "glazba"},
+	"balet":      {"ples", "balet", "glazba"},
+	"medicin":    {"medicina", "zdravlje", "biologija", "pomaganje", "bolnica"},
+	"zdravstv":   {"zdravlje", "medicina", "pomaganje", "ljudi", "bolnica"},
+	"sestr":      {"zdravlje", "medicina", "pomaganje", "ljudi", "bolnica"},
+	"fizioterap": {"zdravlje", "sport", "pomaganje", "ljudi"},
+	"farmac":     {"lijekovi", "kemija", "zdravlje"},
+	"dental":     {"zubi", "zdravlje", "medicina"},
+	"laborator":  {"laboratorij", "kemija", "biologija", "znanost"},
+	"veterin":    {"zivotinje", "psi", "macke", "konji", "zdravlje"},
+	"poljopriv":  {"priroda", "zivotinje", "biljke", "selo"},
+	"agro":       {"priroda", "biljke", "poljoprivreda", "tehnologija"},
+	"vrtlar":     {"biljke", "vrt", "cvijece", "priroda"},
+	"cvjecar":    {"cvijece", "biljke", "dizajn"},
+	"sumar":      {"suma", "priroda", "drvo", "zivotinje"},
+	"frizer":     {"kosa", "frizure", "ljepota", "moda"},
+	"kozmet":     {"sminka", "ljepota", "kozmetika", "moda"},
+	"odjec":      {"moda", "odjeca", "sivanje", "dizajn"},
+	"modn":       {"moda", "odjeca", "dizajn"},
+	"tekstil":    {"moda", "odjeca", "sivanje"},
+	"arhitekt":   {"arhitektura", "gradnja", "crtanje", "dizajn"},
+	"graditelj":  {"gradnja", "kuce", "arhitektura"},
+	"gradjevin":  {"gradnja", "kuce", "arhitektura"},
+	"zidar":      {"gradnja", "kuce", "rucni rad"},
+	"stolar":     {"drvo", "namjestaj", "rucni rad"},
+	"drv":        {"drvo", "namjestaj", "priroda"},
+	"pomorsk":    {"more", "brodovi", "putovanja", "jedrenje"},
+	"nauticar":   {"more", "brodovi", "jedrenje", "putovanja"},
+	"brodo":      {"brodovi", "more", "strojevi"},
+	"zrakoplov":  {"avioni", "letenje", "tehnologija"},
+	"logist":     {"organizacija", "promet", "prijevoz"},
+	"promet":     {"promet", "vozila", "prijevoz"},
+	"vozac":      {"voznja", "kamioni", "vozila"},
+	"sport":      {"sport", "nogomet", "kosarka", "trening", "kretanje"},
+	"fotograf":   {"fotografija", "slikanje", "kamera", "umjetnost"},
+	"medij":      {"mediji", "video", "film", "snimanje", "novinarstvo"},
+	"multimedij": {"video", "film", "animacija", "racunala", "dizajn"},
+	"kemij":      {"kemija", "laboratorij", "znanost"},
+	"ekolog":     {"priroda", "okolis", "ekologija"},
+	"okolis":     {"priroda", "okolis", "ekologija"},
+	"telekomun":  {"mreze", "internet", "tehnologija", "mobiteli"},
+	"instalater": {"popravljanje", "rucni rad", "voda", "grijanje"},
+	"bravar":     {"metal", "rucni rad", "zavarivanje"},
+	"mesar":      {"hrana", "meso"},
+	"socijal":    {"ljudi", "pomaganje", "psihologija"},
+	"odgoj":      {"djeca", "pomaganje", "ucenje"},
+}
+
+// sufiksi - najčešći hrvatski nastavci; uklanja se najdulji koji ostavi barem 3 slova
+var sufiksi = []string{
+	"ovima", "evima", "anje", "enje", "ama", "ima", "ati", "iti", "eti",
+	"ija", "ije", "iju", "om", "em", "ih", "im", "oj", "ju",
+	"a", "e", "i", "o", "u",
+}
+
+// stopRijeci - česte riječi u opisu interesa koje ne govore ništa o programu
+var stopRijeci = map[string]bool{
+	"volim": true, "voli": true, "zelim": true, "zelio": true, "zeljela": true, "htio": true, "htjela": true,
+	"bih": true, "bi": true, "raditi": true, "radim": true, "radila": true, "radio": true, "biti": true,
+	"sam": true, "jako": true, "puno": true, "nesto": true, "stvari": true, "kao": true, "sve": true,
+	"ili": true, "ali": true, "nije": true, "mene": true, "meni": true, "zanima": true, "zanimaju": true,
+	"interes": true, "interesi": true, "hobi": true, "slobodno": true, "vrijeme": true,
+}
+
+// stemRijec - vrlo jednostavan stemmer za normalizirane hrvatske riječi
+func stemRijec(t string) string {
+	for _, s := range sufiksi {
+		if strings.HasSuffix(t, s) && utf8.RuneCountInString(t)-utf8.RuneCountInString(s) >= 3 {
+			return strings.TrimSuffix(t, s)
+		}
+	}
+	return t
+}
+
+// stemovi - tokeni teksta nakon normalizacije i stemiranja (kratke riječi se preskaču)
+func stemovi(s string) []string {
+	var result []string
+	for _, t := range tokeniziraj(s) {
+		if utf8.RuneCountInString(t) < 3 || stopRijeci[t] {
+			continue
+		}
+		result = append(result, stemRijec(t))
+	}
+	return result
+}
+
+// profilPrograma - težinski vektor (stem -> težina) za jedan program
+func profilPrograma(s *models.Skola) map[string]float64 {
+	profil := make(map[string]float64)
+	dodaj := func(tekst string, tezina float64) {
+		for _, st := range stemovi(tekst) {
+			profil[st] += tezina
+		}
+	}
+
+	naziv := NormalizirajTekst(s.Program)
+	dodaj(naziv, offlineTezinaProgram)
+	dodaj(s.VrstaPrograma, offlineTezinaVrsta)
+	for fragment, rijeci := range kljucneRijeciPrograma {
+		if strings.Contains(naziv, fragment) {
+			dodaj(strings.Join(rijeci, " "), offlineTezinaKljucneRijeci)
+		}
+	}
+	return profil
+}
+
+// izvorneRijeci - stem -> riječ kako ju je učenik napisao (za razlog preporuke)
+func izvorneRijeci(interesi string) map[string]string {
+	rijeci := make(map[string]string)
+	for _, w := range strings.FieldsFunc(strings.ToLower(interesi), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		for _, st := range stemovi(w) {
+			if _, postoji := rijeci[st]; !postoji {
+				rijeci[st] = w
+			}
+		}
+	}
+	return rijeci
+}
+
+// pogodeneRijeci - do 3 riječi iz interesa koje najviše doprinose podudaranju s programom
+func pogodeneRijeci(profil map[string]float64, upit map[string]bool, rijeciUpita map[string]string, idf func(string) float64) []string {
+	type pogodak struct {
+		rijec    string
+		doprinos float64
+	}
+	var pogoci []pogodak
+	for st, w := range profil {
+		if upit[st] && rijeciUpita[st] != "" {
+			pogoci = append(pogoci, pogodak{rijeciUpita[st], w * idf(st)})
+		}
+	}
+	sort.Slice(pogoci, func(a, b int) bool {
+		if pogoci[a].doprinos != pogoci[b].doprinos {
+			return pogoci[a].doprinos > pogoci[b].doprinos
+		}
+		return pogoci[a].rijec < pogoci[b].rijec
+	})
+
+	var result []string
+	vidjeno := make(map[string]bool)
+	for _, p := range pogoci {
+		if len(result) == 3 {
+			break
+		}
+		if !vidjeno[p.rijec] {
+			vidjeno[p.rijec] = true
+			result = append(result, p.rijec)
+		}
+	}
+	return result
+}
+
+// OfflinePreporuke - TF-IDF preporuke bez mreže: deterministične i besplatne.
+// Uspoređuje interese s profilom svakog programa (naziv, vrsta programa, ručne ključne riječi).
+func OfflinePreporuke(interesi string, kandidati []models.Skola) models.SugestijeResponse {
+	profili := make([]map[string]float64, len(kandidati))
+	df := make(map[string]int)
+	for i := range kandidati {
+		profili[i] = profilPrograma(&kandidati[i])
+		for st := range profili[i] {
+			df[st]++
+		}
+	}
+
+	idf := func(st string) float64 {
+		return math.Log(float64(len(kandidati)+1)/float64(df[st]+1)) + 1
+	}
+
+	upit := make(map[string]bool)
+	for _, st := range stemovi(interesi) {
+		upit[st] = true
+	}
+	rijeciUpita := izvorneRijeci(interesi)
+
+	type rezultat struct {
+		i      int
+		ocjena float64
+	}
+	var rezultati []rezultat
+
+	normaUpita := 0.0
+	for st := range upit {
+		normaUpita += idf(st) * idf(st)
+	}
+	normaUpita = math.Sqrt(normaUpita)
+
+	if normaUpita > 0 {
+		for i, profil := range profili {
+			// Zbrajanje po sortiranim stemovima: redoslijed mape bi mijenjao zadnje bitove ocjene,
+			// pa bi se programi s (gotovo) jednakom ocjenom različito poredali između poziva
+			redoslijed := make([]string, 0, len(profil))
+			for st := range profil {
+				redoslijed = append(redoslijed, st)
+			}
+			sort.Strings(redoslijed)
+
+			skalarni, norma := 0.0, 0.0
+			for _, st := range redoslijed {
+				w := profil[st]
+				v := w * idf(st)
+				norma += v * v
+				if upit[st] {
+					skalarni += v * idf(st)
+				}
+			}
+			if skalarni == 0 {
+				continue
+			}
+			rezultati = append(rezultati, rezultat{i, skalarni / (math.Sqrt(norma) * normaUpita)})
+		}
+	}
+
+	podudaranje := len(rezultati) > 0
+	if !podudaranje {
+		// Ništa se ne podudara - predlažemo programe s najvećom kvotom
+		for i := range kandidati {
+			rezultati = append(rezultati, rezultat{i, 0})
+		}
+	}
+
+	sort.SliceStable(rezultati, func(a, b int) bool {
+		if rezultati[a].ocjena != rezultati[b].ocjena {
+			return rezultati[a].ocjena > rezultati[b].ocjena
+		}
+		ka, kb := &kandidati[rezultati[a].i], &kandidati[rezultati[b].i]
+		if ka.Kvota != kb.Kvota {
+			return ka.Kvota > kb.Kvota
+		}
+		return ka.SkolaProgramRokId < kb.SkolaProgramRokId
+	})
+
+	programi := []models.ProgramWithID{}
+	poNazivu := make(map[string]int)
+	for _, r := range rezultati {
+		s := &kandidati[r.i]
+		naziv := NormalizirajTekst(s.Program)
+		if poNazivu[naziv] >= offlineMaxIstogPrograma {
+			continue
+		}
+		poNazivu[naziv]++
+		razlog := fmt.Sprintf("Jedan od najtraženijih programa (upisna kvota %d).", s.Kvota)
+		if rijeci := pogodeneRijeci(profili[r.i], upit, rijeciUpita, idf); len(rijeci) > 0 {
+			razlog = "Povezano s tvojim interesima: " + strings.Join(rijeci, ", ") + "."
+		}
+		programi = append(programi, models.ProgramWithID{
+			SkolaProgramRokId: strconv.Itoa(s.SkolaProgramRokId),
+			Program:           strings.TrimSpace(s.Program),
+			Razlog:            razlog,
+			Podudaranje:       math.Round(r.ocjena*1000) / 1000,
+			Oznake:            oznakePrograma(s),
+		})
+		if len(programi) == offlineBrojPrijedloga {
+			break
+		}
+	}
+
+	objasnjenje := "Nismo pronašli programe koji izravno odgovaraju tvojim interesima, pa ti predlažemo neke od najtraženijih smjerova 🙂"
+	if podudaranje {
+		objasnjenje = fmt.Sprintf("Na temelju tvojih interesa izdvojili smo %d programa koji im najbolje odgovaraju 🎯", len(programi))
+	}
+
+	return models.SugestijeResponse{
+		Objasnjenje: objasnjenje,
+		Programi:    programi,
+		Meta:        models.SugestijeMeta{Nacin: NacinOffline},
+	}
+}
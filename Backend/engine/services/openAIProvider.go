@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ddobren/eduformacije/config"
+)
+
+// openAIProvider - bilo koji OpenAI-kompatibilni /chat/completions endpoint (OpenAI, llama.cpp server, Ollama...)
+type openAIProvider struct{}
+
+// openAIChatResponse - pomaže nam parsirati odgovor chat completions API-ja
+type openAIChatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// openAIUsage - potrošnja tokena (u streamu samo uz stream_options.include_usage)
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (u *openAIUsage) potrosnja() TokenPotrosnja {
+	if u == nil {
+		return TokenPotrosnja{}
+	}
+	return TokenPotrosnja{Ulaz: u.PromptTokens, Izlaz: u.CompletionTokens, Ukupno: u.TotalTokens}
+}
+
+func (openAIProvider) Naziv() string { return "openai" }
+
+// openAIZahtjev - priprema POST /chat/completions zahtjev
+func openAIZahtjev(ctx context.Context, req LLMRequest, stream bool) (*http.Request, error) {
+	baseURL := strings.TrimRight(config.GetEnv("OPENAI_BASE_URL", "http://localhost:11434/v1"), "/")
+	apiKey := config.GetEnv("OPENAI_API_KEY", "")
+	model := config.GetEnv("OPENAI_MODEL", "llama3.1")
+
+	payload := map[string]interface{}{
+		"model": model,
+		"messages": []map[string]string{
+			{"role": "user", "content": req.Prompt},
+		},
+		"temperature": 0.2,
+		"stream":      stream,
+	}
+	if stream {
+		payload["stream_options"] = map[string]bool{"include_usage": true}
+	}
+	if req.Shema != nil {
+		// Većina OpenAI-kompatibilnih servera podržava samo općeniti JSON način
+		payload["response_format"] = map[string]string{"type": "json_object"}
+	}
+
+	bodyBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("greška pri pripremi JSON payloada: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/chat/completions", bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("greška pri kreiranju requesta: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	return httpReq, nil
+}
+
+func (openAIProvider) Generate(ctx context.Context, req LLMRequest) (LLMResponse, error) {
+	httpReq, err := openAIZahtjev(ctx, req, false)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+
+	// Lokalni modeli znaju biti spori, pa je timeout duži nego za Gemini
+	client := &http.Client{Timeout: 120 * time.Second}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return LLMResponse{}, fmt.Errorf("greška pri slanju zahtjeva OpenAI-kompatibilnom API-ju: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return LLMResponse{}, fmt.Errorf("greška pri čitanju odgovora: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return LLMResponse{}, novaUpstreamGreska("openai", resp, respBody)
+	}
+
+	var chatResp openAIChatResponse
+	if err := json.Unmarshal(respBody, &chatResp); err != nil {
+		return LLMResponse{}, fmt.Errorf("greška pri parsiranju odgovora: %w", err)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return LLMResponse{}, fmt.Errorf("nema choices u odgovoru AI-ja")
+	}
+
+	return LLMResponse{Tekst: chatResp.Choices[0].Message.Content, Potrosnja: chatResp.Usage.potrosnja()}, nil
+}
+
+func (openAIProvider) GenerateStream(ctx context.Context, req LLMRequest, onChunk func(string)) (LLMResponse, error) {
+	httpReq, err := openAIZahtjev(ctx, req, true)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+
+	client := &http.Client{Timeout: 120 * time.Second}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return LLMResponse{}, fmt.Errorf("greška pri slanju zahtjeva OpenAI-kompatibilnom API-ju: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return LLMResponse{}, novaUpstreamGreska("openai", resp, respBody)
+	}
+
+	var fullText strings.Builder
+	var potrosnja TokenPotrosnja
+	err = citajSSE(resp.Body, func(data string) error {
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("greška pri parsiranju odgovora: %w", err)
+		}
+		if chunk.Usage != nil {
+			potrosnja = chunk.Usage.potrosnja()
+		}
+		for _, c := range chunk.Choices {
+			if c.Delta.Content != "" {
+				fullText.WriteString(c.Delta.Content)
+				onChunk(c.Delta.Content)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return LLMResponse{}, err
+	}
+
+	return LLMResponse{Tekst: fullText.String(), Potrosnja: potrosnja}, nil
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ddobren/eduformacije/database"
+	"github.com/ddobren/eduformacije/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Izvori podataka za pretragu
+const (
+	IzvorProgrami = "programi"
+	IzvorSrednje  = "srednje"
+	IzvorOsnovne  = "osnovne"
+)
+
+// pretragaDokument - jedan zapis spreman za pretragu
+type pretragaDokument struct {
+	izvor  string
+	naziv  string
+	mjesto string
+	adresa string
+	tekst  string             // cijeli normalizirani tekst (za podudaranje fraze)
+	tokeni map[string]float64 // token -> najveća težina polja u kojem se pojavljuje
+	podaci interface{}
+}
+
+// pretragaPolje - vrijednost polja i njegova težina pri rangiranju
+type pretragaPolje struct {
+	vrijednost string
+	tezina     float64
+}
+
+func noviPretragaDokument(izvor, naziv, mjesto, adresa string, podaci interface{}, polja ...pretragaPolje) pretragaDokument {
+	doc := pretragaDokument{
+		izvor:  izvor,
+		naziv:  naziv,
+		mjesto: mjesto,
+		adresa: adresa,
+		tokeni: make(map[string]float64),
+		podaci: podaci,
+	}
+
+	var tekst []string
+	for _, p := range polja {
+		norm := NormalizirajTekst(p.vrijednost)
+		if norm == "" {
+			continue
+		}
+		tekst = append(tekst, norm)
+		for _, t := range strings.Fields(norm) {
+			if p.tezina > doc.tokeni[t] {
+				doc.tokeni[t] = p.tezina
+			}
+		}
+	}
+	doc.tekst = strings.Join(tekst, " | ")
+	return doc
+}
+
+var (
+	pretragaMu sync.RWMutex
+	// Mongo kolekcije se učitavaju pri ažuriranju ili lijeno pri prvoj pretrazi
+	pretragaMongo = map[string][]pretragaDokument{}
+	// Programi se izvode iz indexa škola i ponovno grade kad se promijeni njegova verzija
+	pretragaProgrami        []pretragaDokument
+	pretragaProgramiVerzija int64
+	// Zadnji neuspjeli pokušaj učitavanja kolekcije; do isteka pauze se Mongo ne pita ponovno
+	pretragaNeuspjeh = map[string]time.Time{}
+)
+
+// pretragaPauzaNakonGreske - koliko se čeka prije novog pokušaja učitavanja kolekcije koja nije uspjela
+const pretragaPauzaNakonGreske = 30 * time.Second
+
+// dokumentiKolekcije - dokumenti kolekcije za pretragu; lijeno učitava, ali nakon greške
+// čeka pretragaPauzaNakonGreske da svaka pretraga ne ide ponovno u Mongo
+func dokumentiKolekcije(kolekcija string) ([]pretragaDokument, error) {
+	pretragaMu.RLock()
+	docs, ok := pretragaMongo[kolekcija]
+	neuspjeh := pretragaNeuspjeh[kolekcija]
+	pretragaMu.RUnlock()
+	if ok {
+		return docs, nil
+	}
+	if preostalo := pretragaPauzaNakonGreske - time.Since(neuspjeh); preostalo > 0 {
+		return nil, fmt.Errorf("kolekcija %s nije dostupna, novi pokušaj za %s", kolekcija, preostalo.Round(time.Second))
+	}
+
+	if err := OsvjeziPretraguMongo(kolekcija); err != nil {
+		pretragaMu.Lock()
+		pretragaNeuspjeh[kolekcija] = time.Now()
+		pretragaMu.Unlock()
+		return nil, err
+	}
+
+	pretragaMu.RLock()
+	docs = pretragaMongo[kolekcija]
+	pretragaMu.RUnlock()
+	return docs, nil
+}
+
+// OsvjeziPretraguMongo - ponovno učitava dokumente jedne Mongo kolekcije (srednje/osnovne) u index pretrage
+func OsvjeziPretraguMongo(kolekcija string) error {
+	collection := database.GetMongoCollection("skole", kolekcija)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("greška pri dohvaćanju kolekcije %s: %w", kolekcija, err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []bson.M
+	if err := cursor.All(ctx, &results); err != nil {
+		return fmt.Errorf("greška pri parsiranju kolekcije %s: %w", kolekcija, err)
+	}
+
+	docs := make([]pretragaDokument, 0, len(results))
+	for _, r := range results {
+		naziv := bsonString(r, "Naziv")
+		mjesto := bsonString(r, "Mjesto")
+		adresa := bsonString(r, "Adresa")
+		docs = append(docs, noviPretragaDokument(kolekcija, naziv, mjesto, adresa, r,
+			pretragaPolje{naziv, 3},
+			pretragaPolje{mjesto, 2},
+			pretragaPolje{adresa, 1},
+		))
+	}
+
+	pretragaMu.Lock()
+	pretragaMongo[kolekcija] = docs
+	delete(pretragaNeuspjeh, kolekcija)
+	pretragaMu.Unlock()
+
+	log.Printf("Index pretrage osvježen za kolekciju %s (%d dokumenata)", kolekcija, len(docs))
+	return nil
+}
+
+func bsonString(doc bson.M, key string) string {
+	if v, ok := doc[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// pretragaDokumentiPrograma - vraća dokumente programa za zadanu verziju indexa škola
+func pretragaDokumentiPrograma(idx *SkoleIndex) []pretragaDokument {
+	pretragaMu.RLock()
+	if pretragaProgramiVerzija == idx.Verzija {
+		docs := pretragaProgrami
+		pretragaMu.RUnlock()
+		return docs
+	}
+	pretragaMu.RUnlock()
+
+	docs := make([]pretragaDokument, 0, len(idx.Skole))
+	for i := range idx.Skole {
+		s := idx.Skole[i]
+		docs = append(docs, noviPretragaDokument(IzvorProgrami, s.Skola+" - "+s.Program, s.Mjesto, s.Adresa, s,
+			pretragaPolje{s.Skola, 3},
+			pretragaPolje{s.Program, 3},
+			pretragaPolje{s.Mjesto, 2},
+			pretragaPolje{s.Adresa, 1},
+			pretragaPolje{s.Naziv, 1},
+		))
+	}
+
+	pretragaMu.Lock()
+	pretragaProgrami = docs
+	pretragaProgramiVerzija = idx.Verzija
+	pretragaMu.Unlock()
+
+	return docs
+}
+
+// dozvoljeneGreske - koliko tipfelera tolerira token zadane duljine
+func dozvoljeneGreske(token string) int {
+	n := len([]rune(token))
+	switch {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// ocijeniToken - najbolje podudaranje jednog tokena upita s tokenima dokumenta (0 = nema podudaranja)
+func ocijeniToken(qt string, tokeni map[string]float64) float64 {
+	if w, ok := tokeni[qt]; ok {
+		return w
+	}
+
+	najbolje := 0.0
+	maxGresaka := dozvoljeneGreske(qt)
+	for t, w := range tokeni {
+		var ocjena float64
+		switch {
+		case strings.HasPrefix(t, qt) && len(qt) >= 2:
+			ocjena = 0.8 * w
+		case maxGresaka > 0:
+			if d := levenshtein(qt, t, maxGresaka); d <= maxGresaka {
+				ocjena = (0.7 - 0.15*float64(d-1)) * w
+			}
+		}
+		if ocjena > najbolje {
+			najbolje = ocjena
+		}
+	}
+	return najbolje
+}
+
+// Pretrazi - pretraga po programima (Redis) i školama (Mongo), neosjetljiva na dijakritike i tipfelere.
+// izvori ograničava pretragu na određene izvore (prazno = svi).
+func Pretrazi(ctx context.Context, upit string, izvori []string, limit int) ([]models.PretragaRezultat, error) {
+	qTokeni := tokeniziraj(upit)
+	if len(qTokeni) == 0 {
+		return []models.PretragaRezultat{}, nil
+	}
+	qFraza := strings.Join(qTokeni, " ")
+
+	ukljuci := func(izvor string) bool {
+		if len(izvori) == 0 {
+			return true
+		}
+		for _, i := range izvori {
+			if i == izvor {
+				return true
+			}
+		}
+		return false
+	}
+
+	var skupovi [][]pretragaDokument
+	var greskaIzvora error
+	if ukljuci(IzvorProgrami) {
+		idx, err := GetSkoleIndex(ctx)
+		if err != nil {
+			return nil, err
+		}
+		skupovi = append(skupovi, pretragaDokumentiPrograma(idx))
+	}
+	for _, kolekcija := range []string{IzvorSrednje, IzvorOsnovne} {
+		if !ukljuci(kolekcija) {
+			continue
+		}
+		docs, err := dokumentiKolekcije(kolekcija)
+		if err != nil {
+			log.Printf("Pretraga: preskačem kolekciju %s: %v", kolekcija, err)
+			greskaIzvora = err
+			continue
+		}
+		skupovi = append(skupovi, docs)
+	}
+	// Ako nijedan traženi izvor nije dostupan, prazan rezultat bi bio pogrešan odgovor
+	if len(skupovi) == 0 && greskaIzvora != nil {
+		return nil, greskaIzvora
+	}
+
+	var rezultati []models.PretragaRezultat
+	for _, docs := range skupovi {
+		for i := range docs {
+			doc := &docs[i]
+
+			ukupno := 0.0
+			for _, qt := range qTokeni {
+				o := ocijeniToken(qt, doc.tokeni)
+				if o == 0 {
+					// Svaka riječ upita mora se negdje pojaviti
+					ukupno = 0
+					break
+				}
+				ukupno += o
+			}
+			if ukupno == 0 {
+				continue
+			}
+			if len(qTokeni) > 1 && strings.Contains(doc.tekst, qFraza) {
+				ukupno *= 1.5
+			}
+
+			rezultati = append(rezultati, models.PretragaRezultat{
+				Izvor:        doc.izvor,
+				Naziv:        doc.naziv,
+				Mjesto:       doc.mjesto,
+				Adresa:       doc.adresa,
+				Relevantnost: ukupno / float64(len(qTokeni)),
+				Podaci:       doc.podaci,
+			})
+		}
+	}
+
+	sort.SliceStable(rezultati, func(i, j int) bool {
+		if rezultati[i].Relevantnost != rezultati[j].Relevantnost {
+			return rezultati[i].Relevantnost > rezultati[j].Relevantnost
+		}
+		return rezultati[i].Naziv < rezultati[j].Naziv
+	})
+
+	if len(rezultati) > limit {
+		rezultati = rezultati[:limit]
+	}
+	if rezultati == nil {
+		rezultati = []models.PretragaRezultat{}
+	}
+	return rezultati, nil
+}
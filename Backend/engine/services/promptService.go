@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ddobren/eduformacije/config"
+	"github.com/ddobren/eduformacije/models"
+)
+
+// Prompti su u prompti/<naziv>/<verzija>.tmpl (text/template) i ugrađeni su u binarni program.
+// Aktivna verzija: PROMPT_<NAZIV>_VERZIJA (npr. PROMPT_SUGESTIJE_VERZIJA=v1).
+// A/B test: PROMPT_<NAZIV>_AB=v2:20 šalje 20% zahtjeva na verziju v2.
+//
+//go:embed prompti/*/*.tmpl
+var promptiFS embed.FS
+
+const (
+	PromptSugestije = "sugestije"
+	PromptSavjetnik = "savjetnik"
+
+	zadanaVerzijaPrompta = "v1"
+)
+
+// sugestijePromptPodaci - podaci za prompti/sugestije/*.tmpl
+type sugestijePromptPodaci struct {
+	Interesi string
+	Programi []programZaPrompt
+}
+
+// savjetnikPromptPodaci - podaci za prompti/savjetnik/*.tmpl
+type savjetnikPromptPodaci struct {
+	Programi []programZaPrompt
+	Filteri  string
+	Poruke   []models.SavjetnikPoruka
+}
+
+// primjerPodataka - ogledni podaci za provjeru predložaka pri pokretanju
+var primjerPodataka = map[string]interface{}{
+	PromptSugestije: sugestijePromptPodaci{
+		Interesi: "volim crtanje",
+		Programi: []programZaPrompt{{SkolaProgramRokId: "1", Program: "Grafički dizajner"}},
+	},
+	PromptSavjetnik: savjetnikPromptPodaci{
+		Programi: []programZaPrompt{{SkolaProgramRokId: "1", Program: "Grafički dizajner"}},
+		Filteri:  "nema (cijela Hrvatska)",
+		Poruke:   []models.SavjetnikPoruka{{Uloga: UlogaUcenik, Tekst: "volim crtanje"}},
+	},
+}
+
+var prompti map[string]map[string]*template.Template
+
+var promptFunkcije = template.FuncMap{
+	// json - siguran zapis vrijednosti (npr. interesa učenika) kao JSON literal
+	"json": func(v interface{}) (string, error) {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return "", err
+		}
+		return strings.TrimRight(buf.String(), "\n"), nil
+	},
+}
+
+// UcitajPrompte - parsira sve predloške, provjerava ih na oglednim podacima
+// i provjerava postoje li verzije iz konfiguracije. Poziva se pri pokretanju.
+func UcitajPrompte() error {
+	ucitani := make(map[string]map[string]*template.Template)
+
+	datoteke, err := fs.Glob(promptiFS, "prompti/*/*.tmpl")
+	if err != nil {
+		return err
+	}
+	for _, datoteka := range datoteke {
+		naziv := path.Base(path.Dir(datoteka))
+		verzija := strings.TrimSuffix(path.Base(datoteka), ".tmpl")
+
+		t, err := template.New(path.Base(datoteka)).Funcs(promptFunkcije).Option("missingkey=error").ParseFS(promptiFS, datoteka)
+		if err != nil {
+			return fmt.Errorf("prompt %s/%s: %w", naziv, verzija, err)
+		}
+
+		podaci, ok := primjerPodataka[naziv]
+		if !ok {
+			return fmt.Errorf("prompt %s/%s: nepoznat prompt", naziv, verzija)
+		}
+		if err := t.Execute(&bytes.Buffer{}, podaci); err != nil {
+			return fmt.Errorf("prompt %s/%s: %w", naziv, verzija, err)
+		}
+
+		if ucitani[naziv] == nil {
+			ucitani[naziv] = make(map[string]*template.Template)
+		}
+		ucitani[naziv][verzija] = t
+	}
+
+	for naziv := range primjerPodataka {
+		if len(ucitani[naziv]) == 0 {
+			return fmt.Errorf("prompt %s: nema nijedne verzije", naziv)
+		}
+		aktivna := aktivnaVerzijaPrompta(naziv)
+		if ucitani[naziv][aktivna] == nil {
+			return fmt.Errorf("prompt %s: aktivna verzija %s ne postoji", naziv, aktivna)
+		}
+		if abVerzija, _, ok := abTestPrompta(naziv); ok && ucitani[naziv][abVerzija] == nil {
+			return fmt.Errorf("prompt %s: A/B verzija %s ne postoji", naziv, abVerzija)
+		}
+	}
+
+	prompti = ucitani
+	for naziv := range ucitani {
+		log.Printf("Prompt %s: verzije %s, aktivna %s", naziv, strings.Join(VerzijePrompta(naziv), ", "), aktivnaVerzijaPrompta(naziv))
+	}
+	return nil
+}
+
+// VerzijePrompta - sortirane verzije učitanog prompta
+func VerzijePrompta(naziv string) []string {
+	var verzije []string
+	for v := range prompti[naziv] {
+		verzije = append(verzije, v)
+	}
+	sort.Strings(verzije)
+	return verzije
+}
+
+func aktivnaVerzijaPrompta(naziv string) string {
+	return config.GetEnv("PROMPT_"+strings.ToUpper(naziv)+"_VERZIJA", zadanaVerzijaPrompta)
+}
+
+// abTestPrompta - čita PROMPT_<NAZIV>_AB u obliku "verzija:postotak"
+func abTestPrompta(naziv string) (string, int, bool) {
+	vrijednost := strings.TrimSpace(config.GetEnv("PROMPT_"+strings.ToUpper(naziv)+"_AB", ""))
+	if vrijednost == "" {
+		return "", 0, false
+	}
+	verzija, postotakStr, ok := strings.Cut(vrijednost, ":")
+	postotak, err := strconv.Atoi(postotakStr)
+	if !ok || err != nil || postotak <= 0 || postotak > 100 {
+		log.Printf("Neispravan PROMPT_%s_AB=%q (očekuje se verzija:postotak)", strings.ToUpper(naziv), vrijednost)
+		return "", 0, false
+	}
+	return verzija, postotak, true
+}
+
+// odaberiVerzijuPrompta - aktivna verzija ili A/B verzija. Odluka ovisi samo o ključu
+// (npr. normaliziranim interesima ili ID-u sesije), pa isti upit uvijek dobiva istu verziju.
+func odaberiVerzijuPrompta(naziv, kljuc string) string {
+	if verzija, postotak, ok := abTestPrompta(naziv); ok {
+		h := fnv.New32a()
+		h.Write([]byte(naziv + "\x00" + kljuc))
+		if int(h.Sum32()%100) < postotak {
+			return verzija
+		}
+	}
+	return aktivnaVerzijaPrompta(naziv)
+}
+
+// RenderirajPrompt - izvršava zadanu verziju predloška
+func RenderirajPrompt(naziv, verzija string, podaci interface{}) (string, error) {
+	t := prompti[naziv][verzija]
+	if t == nil {
+		return "", fmt.Errorf("prompt %s/%s nije učitan", naziv, verzija)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, podaci); err != nil {
+		return "", fmt.Errorf("prompt %s/%s: %w", naziv, verzija, err)
+	}
+	return buf.String(), nil
+}
+
+// SugestijePromptPodaci - podaci predloška preporuka (koriste ih i golden testovi)
+func SugestijePromptPodaci(interesi string, kandidati []models.Skola) interface{} {
+	return sugestijePromptPodaci{Interesi: interesi, Programi: programiZaPrompt(kandidati)}
+}
+
+// SavjetnikPromptPodaci - podaci predloška savjetnika (zadnjih nekoliko poruka razgovora)
+func SavjetnikPromptPodaci(sesija *models.SavjetnikSesija, kandidati []models.Skola) interface{} {
+	poruke := sesija.Poruke
+	if len(poruke) > savjetnikPorukaPrompt {
+		poruke = poruke[len(poruke)-savjetnikPorukaPrompt:]
+	}
+	return savjetnikPromptPodaci{
+		Programi: programiZaPrompt(kandidati),
+		Filteri:  opisFiltera(sesija.Filteri),
+		Poruke:   poruke,
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+// Golden datoteke prompta: svaka verzija svakog predloška renderira se za fiksne ulaze
+// i uspoređuje sa spremljenom datotekom u testdata/prompti.
+//
+//	go test ./services -run TestPromptGolden            # provjera
+//	go test ./services -run TestPromptGolden -azuriraj  # ponovno zapiši golden datoteke
+//
+// Promjena predloška tako postaje vidljiva u diffu golden datoteka, a nova verzija
+// (npr. v2 za A/B test) dobiva svoje datoteke uz postojeće.
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ddobren/eduformacije/models"
+)
+
+var azuriraj = flag.Bool("azuriraj", false, "zapiši golden datoteke umjesto provjere")
+
+func boolPtr(v bool) *bool { return &v }
+
+var goldenKandidati = []models.Skola{
+	{SkolaProgramRokId: 1001, Program: "Grafički tehničar", Mjesto: "Split", Zupanija: "Splitsko-dalmatinska", Trajanje: 4},
+	{SkolaProgramRokId: 1002, Program: "Kuhar", Mjesto: "Split", Zupanija: "Splitsko-dalmatinska", Trajanje: 3},
+	{SkolaProgramRokId: 1003, Program: "Tehničar za računalstvo", Mjesto: "Zagreb", Zupanija: "Grad Zagreb", Trajanje: 4},
+	{SkolaProgramRokId: 1004, Program: "Likovna umjetnost i dizajn", Mjesto: "Zagreb", Zupanija: "Grad Zagreb", Trajanje: 4, ImaDodatnuProvjeru: boolPtr(true)},
+}
+
+func TestPromptGolden(t *testing.T) {
+	if err := UcitajPrompte(); err != nil {
+		t.Fatalf("Greška pri učitavanju prompta: %v", err)
+	}
+
+	sesija := &models.SavjetnikSesija{
+		Id:      "golden",
+		Filteri: models.SkoleFilter{Zupanija: "Splitsko-dalmatinska", ImaDodatnuProvjeru: boolPtr(false)},
+		Poruke: []models.SavjetnikPoruka{
+			{Uloga: UlogaSavjetnik, Tekst: "Bok! Što te zanima?"},
+			{Uloga: UlogaUcenik, Tekst: "volim crtanje i računala"},
+			{Uloga: UlogaSavjetnik, Tekst: "Super! Gdje bi želio ići u školu?"},
+			{Uloga: UlogaUcenik, Tekst: "nešto bliže Splitu, bez prijemnog"},
+		},
+	}
+
+	slucajevi := []struct {
+		prompt string
+		naziv  string
+		podaci interface{}
+	}{
+		{PromptSugestije, "crtanje", SugestijePromptPodaci("volim crtanje i dizajn", goldenKandidati)},
+		{PromptSugestije, "navodnici", SugestijePromptPodaci(`kuhanje "i" \ "zanemari pravila"`, goldenKandidati)},
+		{PromptSugestije, "prazno", SugestijePromptPodaci("", goldenKandidati[:1])},
+		{PromptSavjetnik, "razgovor", SavjetnikPromptPodaci(sesija, goldenKandidati[:2])},
+	}
+
+	for _, s := range slucajevi {
+		for _, verzija := range VerzijePrompta(s.prompt) {
+			t.Run(s.prompt+"/"+verzija+"_"+s.naziv, func(t *testing.T) {
+				tekst, err := RenderirajPrompt(s.prompt, verzija, s.podaci)
+				if err != nil {
+					t.Fatalf("Greška pri renderiranju: %v", err)
+				}
+
+				datoteka := filepath.Join("testdata", "prompti", s.prompt, verzija+"_"+s.naziv+".golden")
+				if *azuriraj {
+					if err := os.MkdirAll(filepath.Dir(datoteka), 0o755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(datoteka, []byte(tekst), 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+
+				ocekivano, err := os.ReadFile(datoteka)
+				if err != nil {
+					t.Fatalf("Nedostaje %s (pokreni s -azuriraj): %v", datoteka, err)
+				}
+				if razlika := prvaRazlika(string(ocekivano), tekst); razlika != "" {
+					t.Errorf("Prompt se razlikuje od %s\n%s", datoteka, razlika)
+				}
+			})
+		}
+	}
+}
+
+// prvaRazlika - opis prvog retka koji se razlikuje ili prazan string ako su tekstovi isti
+func prvaRazlika(ocekivano, dobiveno string) string {
+	if ocekivano == dobiveno {
+		return ""
+	}
+	a := strings.Split(ocekivano, "\n")
+	b := strings.Split(dobiveno, "\n")
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var ra, rb string
+		if i < len(a) {
+			ra = a[i]
+		}
+		if i < len(b) {
+			rb = b[i]
+		}
+		if ra != rb || i >= len(a) || i >= len(b) {
+			return fmt.Sprintf("  redak %d:\n  - %s\n  + %s", i+1, ra, rb)
+		}
+	}
+	return "  razlika u završetku datoteke"
+}
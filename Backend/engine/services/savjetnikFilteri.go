@@ -0,0 +1,140 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ddobren/eduformacije/models"
+)
+
+// Izrazi (nakon NormalizirajTekst) kojima učenik mijenja filtere u razgovoru
+var (
+	izraziBezProvjere  = []string{"bez prijemn", "bez dodatn", "bez ispit", "bez provjer"}
+	izraziSaProvjerom  = []string{"s prijemn", "sa prijemn", "s dodatn", "sa dodatn", "s ispit", "sa ispit"}
+	izraziBlizu        = []string{"blizu", "blize", "okolic", "u blizini"}
+	izraziBiloGdje     = []string{"bilo gdje", "svejedno gdje", "cijela hrvatska", "cijeloj hrvatskoj", "bilo koji grad"}
+	izraziPrivatna     = []string{"privatn"}
+	izraziJavna        = []string{"javna skol", "javnu skol", "javne skol", "javnoj skol", "drzavn"}
+	izraziVjerska      = []string{"vjersk", "katolick", "crkven"}
+	maxNastavakMjesta  = 2 // "Split" -> "Splitu", "Zagreb" -> "Zagrebu"
+	minDuljinaPrefiksa = 4
+)
+
+// sadrziIzraz - sadrži li normalizirani tekst neki od izraza na početku riječi
+func sadrziIzraz(tekst string, izrazi []string) bool {
+	tekst = " " + tekst
+	for _, izraz := range izrazi {
+		if strings.Contains(tekst, " "+izraz) {
+			return true
+		}
+	}
+	return false
+}
+
+// rijecOdgovara - je li riječ iz poruke oblik riječi iz naziva (isti stem ili kratak nastavak).
+// Kratki nazivi ("Vis", "Rab") moraju se poklopiti točno, inače bi "više" značilo Vis.
+func rijecOdgovara(rijec, naziv string) bool {
+	if rijec == naziv {
+		return true
+	}
+	if utf8.RuneCountInString(naziv) < minDuljinaPrefiksa {
+		return false
+	}
+	if stemRijec(rijec) == stemRijec(naziv) {
+		return true
+	}
+	return strings.HasPrefix(rijec, naziv) &&
+		utf8.RuneCountInString(rijec)-utf8.RuneCountInString(naziv) <= maxNastavakMjesta
+}
+
+// sadrziNaziv - pojavljuju li se sve riječi naziva redom u tokenima poruke
+func sadrziNaziv(tokeni []string, naziv string) bool {
+	rijeci := tokeniziraj(naziv)
+	if len(rijeci) == 0 {
+		return false
+	}
+	for i := 0; i+len(rijeci) <= len(tokeni); i++ {
+		odgovara := true
+		for j, r := range rijeci {
+			if !rijecOdgovara(tokeni[i+j], r) {
+				odgovara = false
+				break
+			}
+		}
+		if odgovara {
+			return true
+		}
+	}
+	return false
+}
+
+// najduziNaziv - najduži naziv iz liste koji se spominje u poruci ("Slavonski Brod" prije "Brod")
+func najduziNaziv(tokeni []string, nazivi []string) string {
+	najbolji := ""
+	for _, n := range nazivi {
+		if len(n) > len(najbolji) && sadrziNaziv(tokeni, n) {
+			najbolji = n
+		}
+	}
+	return najbolji
+}
+
+// vrstaOsnivacaPoPrefiksu - vrsta osnivača iz indexa koja počinje zadanim normaliziranim prefiksom
+func vrstaOsnivacaPoPrefiksu(idx *SkoleIndex, prefiks string) string {
+	for _, v := range idx.VrsteOsnivaca {
+		if strings.HasPrefix(NormalizirajTekst(v), prefiks) {
+			return v
+		}
+	}
+	return ""
+}
+
+// izvuciFiltere - pravilima (bez AI-ja) iz poruke čita promjene filtera:
+// mjesto ili županiju iz skupa podataka, "bliže X" (županija mjesta X), "bilo gdje",
+// "bez prijemnog" / "s prijemnim" te vrstu osnivača (privatna, javna, vjerska).
+func izvuciFiltere(poruka string, idx *SkoleIndex, f models.SkoleFilter) models.SkoleFilter {
+	tekst := NormalizirajTekst(poruka)
+	tokeni := tokeniziraj(poruka)
+
+	switch {
+	case sadrziIzraz(tekst, izraziBezProvjere):
+		ne := false
+		f.ImaDodatnuProvjeru = &ne
+	case sadrziIzraz(tekst, izraziSaProvjerom):
+		da := true
+		f.ImaDodatnuProvjeru = &da
+	}
+
+	switch {
+	case sadrziIzraz(tekst, izraziPrivatna):
+		f.VrstaOsnivaca = vrstaOsnivacaPoPrefiksu(idx, "privatn")
+	case sadrziIzraz(tekst, izraziVjerska):
+		f.VrstaOsnivaca = vrstaOsnivacaPoPrefiksu(idx, "ustanova vjersk")
+	case sadrziIzraz(tekst, izraziJavna):
+		f.VrstaOsnivaca = vrstaOsnivacaPoPrefiksu(idx, "javn")
+	}
+
+	if sadrziIzraz(tekst, izraziBiloGdje) {
+		f.Mjesto, f.Zupanija = "", ""
+		return f
+	}
+
+	if mjesto := najduziNaziv(tokeni, idx.Mjesta); mjesto != "" {
+		if sadrziIzraz(tekst, izraziBlizu) {
+			// "bliže Splitu" - cijela županija, a ne samo taj grad
+			if programi := idx.Filtriraj(models.SkoleFilter{Mjesto: mjesto}); len(programi) > 0 {
+				f.Zupanija = strings.TrimSpace(programi[0].Zupanija)
+				f.Mjesto = ""
+				return f
+			}
+		}
+		f.Mjesto, f.Zupanija = mjesto, ""
+		return f
+	}
+
+	if zupanija := najduziNaziv(tokeni, idx.Zupanije); zupanija != "" {
+		f.Zupanija, f.Mjesto = zupanija, ""
+	}
+
+	return f
+}
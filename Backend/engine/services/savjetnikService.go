@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ddobren/eduformacije/config"
+	"github.com/ddobren/eduformacije/database"
+	"github.com/ddobren/eduformacije/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	savjetnikSesijaPrefix = "savjetnik:sesija:"
+	savjetnikMaxPoruka    = 30 // starije poruke se brišu iz sesije
+	savjetnikPorukaPrompt = 10 // koliko zadnjih poruka ide u prompt
+
+	UlogaUcenik    = "ucenik"
+	UlogaSavjetnik = "savjetnik"
+)
+
+// ErrSesijaNePostoji - sesija ne postoji ili je istekla
+var ErrSesijaNePostoji = errors.New("sesija ne postoji ili je istekla")
+
+var savjetnikShema = &JSONShema{
+	Type:     ShemaObject,
+	Required: []string{"odgovor", "programi"},
+	Properties: map[string]*JSONShema{
+		"odgovor":  {Type: ShemaString, NePrazno: true, Description: "Odgovor učeniku na hrvatskom"},
+		"pitanje":  {Type: ShemaString, Description: "Dodatno pitanje za suženje izbora ili prazno"},
+		"programi": {Type: ShemaArray, MinItems: intPtr(1), Items: programShema},
+	},
+}
+
+// savjetnikSesijaTTL - koliko dugo se neaktivna sesija čuva (SAVJETNIK_SESIJA_TTL)
+func savjetnikSesijaTTL() time.Duration {
+	ttl, err := time.ParseDuration(config.GetEnv("SAVJETNIK_SESIJA_TTL", "2h"))
+	if err != nil || ttl <= 0 {
+		return 2 * time.Hour
+	}
+	return ttl
+}
+
+func novaSesijaId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NovaSesija - stvara praznu sesiju s početnim filterima (još nije spremljena).
+// Vlasnik je "sub" tokena; samo on smije čitati sesiju i slati poruke.
+func NovaSesija(filteri models.SkoleFilter, vlasnik string) (*models.SavjetnikSesija, error) {
+	id, err := novaSesijaId()
+	if err != nil {
+		return nil, fmt.Errorf("greška pri generiranju ID-a sesije: %w", err)
+	}
+	sada := time.Now()
+	return &models.SavjetnikSesija{
+		Id:        id,
+		Vlasnik:   vlasnik,
+		Kreirana:  sada,
+		Azurirana: sada,
+		Filteri:   filteri,
+		Poruke:    []models.SavjetnikPoruka{},
+		Programi:  []models.ProgramWithID{},
+	}, nil
+}
+
+// DohvatiSesiju - čita sesiju iz Redisa
+func DohvatiSesiju(ctx context.Context, id string) (*models.SavjetnikSesija, error) {
+	data, err := database.GetRedisClient().Get(ctx, savjetnikSesijaPrefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSesijaNePostoji
+	}
+	if err != nil {
+		return nil, fmt.Errorf("greška prilikom čitanja sesije iz Redisa: %w", err)
+	}
+
+	var sesija models.SavjetnikSesija
+	if err := json.Unmarshal(data, &sesija); err != nil {
+		return nil, fmt.Errorf("neispravna sesija u Redisu: %w", err)
+	}
+	return &sesija, nil
+}
+
+// SpremiSesiju - sprema sesiju i produljuje joj trajanje
+func SpremiSesiju(ctx context.Context, sesija *models.SavjetnikSesija) error {
+	if len(sesija.Poruke) > savjetnikMaxPoruka {
+		sesija.Poruke = sesija.Poruke[len(sesija.Poruke)-savjetnikMaxPoruka:]
+	}
+	sesija.Azurirana = time.Now()
+
+	data, err := json.Marshal(sesija)
+	if err != nil {
+		return err
+	}
+	if err := database.GetRedisClient().Set(ctx, savjetnikSesijaPrefix+sesija.Id, data, savjetnikSesijaTTL()).Err(); err != nil {
+		return fmt.Errorf("greška pri spremanju sesije u Redis: %w", err)
+	}
+	return nil
+}
+
+// PozdravSavjetnika - početna poruka sesije bez prve poruke učenika
+func PozdravSavjetnika(sesija *models.SavjetnikSesija) models.SavjetnikOdgovor {
+	odgovor := models.SavjetnikOdgovor{
+		SesijaId: sesija.Id,
+		Odgovor:  "Bok! 👋 Pomoći ću ti pronaći srednju školu koja ti odgovara.",
+		Pitanje:  "Reci mi što te zanima i što voliš raditi u slobodno vrijeme?",
+		Programi: sesija.Programi,
+		Filteri:  sesija.Filteri,
+	}
+	dodajPoruku(sesija, UlogaSavjetnik, odgovor.Odgovor+" "+odgovor.Pitanje)
+	return odgovor
+}
+
+func dodajPoruku(sesija *models.SavjetnikSesija, uloga, tekst string) {
+	sesija.Poruke = append(sesija.Poruke, models.SavjetnikPoruka{
+		Uloga:   uloga,
+		Tekst:   tekst,
+		Vrijeme: time.Now(),
+	})
+}
+
+// interesiIzSesije - sve poruke učenika zajedno (za offline preporuke)
+func interesiIzSesije(sesija *models.SavjetnikSesija) string {
+	var dijelovi []string
+	for _, p := range sesija.Poruke {
+		if p.Uloga == UlogaUcenik {
+			dijelovi = append(dijelovi, p.Tekst)
+		}
+	}
+	return strings.Join(dijelovi, ". ")
+}
+
+// PosaljiPoruku - dodaje poruku učenika, ažurira filtere i vraća novi odgovor savjetnika.
+// Sesiju mijenja na mjestu; spremanje je na pozivatelju.
+func PosaljiPoruku(ctx context.Context, sesija *models.SavjetnikSesija, poruka string, idx *SkoleIndex) (models.SavjetnikOdgovor, error) {
+	dodajPoruku(sesija, UlogaUcenik, poruka)
+
+	prethodni := sesija.Filteri
+	sesija.Filteri = izvuciFiltere(poruka, idx, sesija.Filteri)
+
+	kandidati := idx.Filtriraj(sesija.Filteri)
+	if len(kandidati) == 0 {
+		// Novi uvjeti ne ostavljaju nijedan program - vraćamo stare filtere i pitamo učenika
+		sesija.Filteri = prethodni
+		odgovor := models.SavjetnikOdgovor{
+			SesijaId: sesija.Id,
+			Odgovor:  "Za te uvjete nisam pronašao nijedan program 😕",
+			Pitanje:  "Želiš li da proširim pretragu, npr. na cijelu županiju ili bez nekog od uvjeta?",
+			Programi: sesija.Programi,
+			Filteri:  sesija.Filteri,
+		}
+		dodajPoruku(sesija, UlogaSavjetnik, odgovor.Odgovor+" "+odgovor.Pitanje)
+		return odgovor, nil
+	}
+
+	odgovor, err := odgovorSavjetnika(ctx, sesija, kandidati)
+	if err != nil {
+		return models.SavjetnikOdgovor{}, err
+	}
+
+	odgovor.SesijaId = sesija.Id
+	odgovor.Filteri = sesija.Filteri
+	sesija.Programi = odgovor.Programi
+
+	tekst := odgovor.Odgovor
+	if odgovor.Pitanje != "" {
+		tekst += " " + odgovor.Pitanje
+	}
+	dodajPoruku(sesija, UlogaSavjetnik, tekst)
+
+	return odgovor, nil
+}
+
+// odgovorSavjetnika - AI odgovor prema SUGESTIJE_NACIN, uz offline rezervu kao kod preporuka
+func odgovorSavjetnika(ctx context.Context, sesija *models.SavjetnikSesija, kandidati []models.Skola) (models.SavjetnikOdgovor, error) {
+	if config.GetEnv("SUGESTIJE_NACIN", NacinAI) == NacinOffline {
+		return offlineOdgovorSavjetnika(sesija, kandidati), nil
+	}
+
+	odgovor, err := aiOdgovorSavjetnika(ctx, sesija, kandidati)
+	if err == nil {
+		return odgovor, nil
+	}
+	if !koristiRezervu(err) {
+		return models.SavjetnikOdgovor{}, err
+	}
+
+	log.Printf("AI savjetnik nije uspio, koristim offline način: %v", err)
+	odgovor = offlineOdgovorSavjetnika(sesija, kandidati)
+	odgovor.Meta.Rezerva = true
+	odgovor.Meta.Degradirano = AINedostupan(err)
+	return odgovor, nil
+}
+
+func aiOdgovorSavjetnika(ctx context.Context, sesija *models.SavjetnikSesija, kandidati []models.Skola) (models.SavjetnikOdgovor, error) {
+	prompt, verzija, err := savjetnikPrompt(sesija, kandidati)
+	if err != nil {
+		return models.SavjetnikOdgovor{}, err
+	}
+
+	provider, err := GetLLMProvider()
+	if err != nil {
+		return models.SavjetnikOdgovor{}, err
+	}
+
+	var aiResponse struct {
+		Odgovor  string                 `json:"odgovor"`
+		Pitanje  string                 `json:"pitanje"`
+		Programi []models.ProgramWithID `json:"programi"`
+	}
+	if _, err := GenerirajJSON(ctx, provider, prompt, savjetnikShema, &aiResponse); err != nil {
+		return models.SavjetnikOdgovor{}, err
+	}
+
+	programi, meta := validirajPrograme(aiResponse.Programi, kandidati)
+	meta.Nacin = NacinAI
+	meta.PromptVerzija = verzija
+
+	return models.SavjetnikOdgovor{
+		Odgovor:  aiResponse.Odgovor,
+		Pitanje:  strings.TrimSpace(aiResponse.Pitanje),
+		Programi: programi,
+		Meta:     meta,
+	}, nil
+}
+
+// offlineOdgovorSavjetnika - TF-IDF preporuke nad svim porukama učenika i jednostavno pitanje za suženje
+func offlineOdgovorSavjetnika(sesija *models.SavjetnikSesija, kandidati []models.Skola) models.SavjetnikOdgovor {
+	resp := OfflinePreporuke(interesiIzSesije(sesija), kandidati)
+
+	pitanje := ""
+	switch {
+	case sesija.Filteri.Mjesto == "" && sesija.Filteri.Zupanija == "":
+		pitanje = "U kojem gradu ili županiji bi želio/željela ići u školu?"
+	case sesija.Filteri.ImaDodatnuProvjeru == nil:
+		pitanje = "Je li ti u redu program s prijemnim ispitom ili dodatnom provjerom?"
+	}
+
+	return models.SavjetnikOdgovor{
+		Odgovor:  resp.Objasnjenje,
+		Pitanje:  pitanje,
+		Programi: resp.Programi,
+		Meta:     resp.Meta,
+	}
+}
+
+// opisFiltera - trenutni filteri sesije čitljivo za prompt
+func opisFiltera(f models.SkoleFilter) string {
+	var dijelovi []string
+	if f.Mjesto != "" {
+		dijelovi = append(dijelovi, "mjesto: "+f.Mjesto)
+	}
+	if f.Zupanija != "" {
+		dijelovi = append(dijelovi, "županija: "+f.Zupanija)
+	}
+	if f.VrstaOsnivaca != "" {
+		dijelovi = append(dijelovi, "osnivač: "+f.VrstaOsnivaca)
+	}
+	if f.ImaDodatnuProvjeru != nil {
+		if *f.ImaDodatnuProvjeru {
+			dijelovi = append(dijelovi, "samo programi s dodatnom provjerom")
+		} else {
+			dijelovi = append(dijelovi, "bez prijemnog ispita i dodatne provjere")
+		}
+	}
+	if len(dijelovi) == 0 {
+		return "nema (cijela Hrvatska)"
+	}
+	return strings.Join(dijelovi, ", ")
+}
+
+// savjetnikPrompt - prompt s kandidatima, trenutnim filterima i zadnjim porukama razgovora.
+// A/B verzija se bira po ID-u sesije, pa cijeli razgovor koristi istu verziju.
+func savjetnikPrompt(sesija *models.SavjetnikSesija, kandidati []models.Skola) (string, string, error) {
+	verzija := odaberiVerzijuPrompta(PromptSavjetnik, sesija.Id)
+	prompt, err := RenderirajPrompt(PromptSavjetnik, verzija, SavjetnikPromptPodaci(sesija, kandidati))
+	return prompt, verzija, err
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ddobren/eduformacije/database"
+	"github.com/ddobren/eduformacije/models"
+)
+
+// SkoleIndex - nepromjenjivi snapshot programa srednjih škola s pomoćnim indeksima.
+// Nakon izgradnje se nikad ne mijenja, pa ga handleri smiju čitati bez zaključavanja.
+type SkoleIndex struct {
+	Verzija   int64
+	Izgradjen time.Time
+	Skole     []models.Skola
+
+	// Sortirane jedinstvene vrijednosti (za padajuće izbornike)
+	Zupanije      []string
+	Mjesta        []string
+	VrsteOsnivaca []string
+
+	poZupaniji      map[string][]int
+	poMjestu        map[string][]int
+	poVrstiOsnivaca map[string][]int
+	poVrstiPrograma map[string][]int
+	poSkolaId       map[int][]int
+	poProgramRokId  map[int]int
+	geoCelije       map[geoCelija][]int
+	grupe           []models.SkolaDetalji
+	grupaPoId       map[int]int
+}
+
+var (
+	skoleIndex        atomic.Pointer[SkoleIndex]
+	skoleIndexVerzija atomic.Int64
+	skoleIndexMu      sync.Mutex
+)
+
+// indexKljuc - normalizira vrijednost za pretraživanje po indeksu
+func indexKljuc(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// buildSkoleIndex - gradi novi index iz liste programa
+func buildSkoleIndex(skole []models.Skola) *SkoleIndex {
+	idx := &SkoleIndex{
+		Verzija:         skoleIndexVerzija.Add(1),
+		Izgradjen:       time.Now(),
+		Skole:           skole,
+		poZupaniji:      make(map[string][]int),
+		poMjestu:        make(map[string][]int),
+		poVrstiOsnivaca: make(map[string][]int),
+		poVrstiPrograma: make(map[string][]int),
+		poSkolaId:       make(map[int][]int),
+		poProgramRokId:  make(map[int]int, len(skole)),
+	}
+
+	zupanije := make(map[string]bool)
+	mjesta := make(map[string]bool)
+	vrste := make(map[string]bool)
+
+	for i, s := range skole {
+		idx.poZupaniji[indexKljuc(s.Zupanija)] = append(idx.poZupaniji[indexKljuc(s.Zupanija)], i)
+		idx.poMjestu[indexKljuc(s.Mjesto)] = append(idx.poMjestu[indexKljuc(s.Mjesto)], i)
+		idx.poVrstiOsnivaca[indexKljuc(s.VrstaOsnivaca)] = append(idx.poVrstiOsnivaca[indexKljuc(s.VrstaOsnivaca)], i)
+		idx.poVrstiPrograma[indexKljuc(s.VrstaPrograma)] = append(idx.poVrstiPrograma[indexKljuc(s.VrstaPrograma)], i)
+		idx.poSkolaId[s.SkolaId] = append(idx.poSkolaId[s.SkolaId], i)
+		idx.poProgramRokId[s.SkolaProgramRokId] = i
+
+		zupanije[strings.TrimSpace(s.Zupanija)] = true
+		mjesta[strings.TrimSpace(s.Mjesto)] = true
+		vrste[strings.TrimSpace(s.VrstaOsnivaca)] = true
+	}
+
+	idx.geoCelije = buildGeoIndex(skole)
+	idx.grupe, idx.grupaPoId = buildGrupeSkola(skole, idx.poSkolaId)
+	idx.Zupanije = sortiraneVrijednosti(zupanije)
+	idx.Mjesta = sortiraneVrijednosti(mjesta)
+	idx.VrsteOsnivaca = sortiraneVrijednosti(vrste)
+
+	return idx
+}
+
+func sortiraneVrijednosti(set map[string]bool) []string {
+	result := make([]string, 0, len(set))
+	for v := range set {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// RebuildSkoleIndex - parsira JSON programa i atomski zamjenjuje aktivni index
+func RebuildSkoleIndex(data []byte) (*SkoleIndex, error) {
+	var skole []models.Skola
+	if err := json.Unmarshal(data, &skole); err != nil {
+		return nil, fmt.Errorf("greška pri parsiranju podataka o školama: %w", err)
+	}
+
+	idx := buildSkoleIndex(skole)
+	skoleIndex.Store(idx)
+
+	log.Printf("Index škola izgrađen (verzija %d, %d programa)", idx.Verzija, len(skole))
+	return idx, nil
+}
+
+// GetSkoleIndex - vraća aktivni index; ako još ne postoji, gradi ga iz Redisa
+func GetSkoleIndex(ctx context.Context) (*SkoleIndex, error) {
+	if idx := skoleIndex.Load(); idx != nil {
+		return idx, nil
+	}
+
+	skoleIndexMu.Lock()
+	defer skoleIndexMu.Unlock()
+
+	// Netko je možda izgradio index dok smo čekali na lock
+	if idx := skoleIndex.Load(); idx != nil {
+		return idx, nil
+	}
+
+	rdb := database.GetRedisClient()
+	data, err := rdb.Get(ctx, "skole_json").Result()
+	if err != nil {
+		return nil, fmt.Errorf("greška prilikom čitanja iz Redisa: %w", err)
+	}
+
+	return RebuildSkoleIndex([]byte(data))
+}
+
+// Filtriraj - vraća programe koji zadovoljavaju filter.
+// Kreće od najmanjeg skupa iz sekundarnih indeksa, a ostale uvjete provjerava redom.
+func (idx *SkoleIndex) Filtriraj(f models.SkoleFilter) []models.Skola {
+	var kandidati []int
+	suzeno := false
+
+	suzi := func(indeks map[string][]int, vrijednost string) {
+		if vrijednost == "" {
+			return
+		}
+		lista := indeks[indexKljuc(vrijednost)]
+		if !suzeno || len(lista) < len(kandidati) {
+			kandidati = lista
+			suzeno = true
+		}
+	}
+	suzi(idx.poZupaniji, f.Zupanija)
+	suzi(idx.poMjestu, f.Mjesto)
+	suzi(idx.poVrstiOsnivaca, f.VrstaOsnivaca)
+	suzi(idx.poVrstiPrograma, f.VrstaPrograma)
+
+	var filtered []models.Skola
+	if suzeno {
+		for _, i := range kandidati {
+			if OdgovaraFilteru(&idx.Skole[i], f) {
+				filtered = append(filtered, idx.Skole[i])
+			}
+		}
+	} else {
+		for i := range idx.Skole {
+			if OdgovaraFilteru(&idx.Skole[i], f) {
+				filtered = append(filtered, idx.Skole[i])
+			}
+		}
+	}
+
+	return filtered
+}
+
+// OdgovaraFilteru - provjerava zadovoljava li program sve postavljene filtere
+func OdgovaraFilteru(s *models.Skola, f models.SkoleFilter) bool {
+	if f.Zupanija != "" && indexKljuc(s.Zupanija) != indexKljuc(f.Zupanija) {
+		return false
+	}
+	if f.Mjesto != "" && indexKljuc(s.Mjesto) != indexKljuc(f.Mjesto) {
+		return false
+	}
+	if f.VrstaOsnivaca != "" && indexKljuc(s.VrstaOsnivaca) != indexKljuc(f.VrstaOsnivaca) {
+		return false
+	}
+	if f.VrstaPrograma != "" && indexKljuc(s.VrstaPrograma) != indexKljuc(f.VrstaPrograma) {
+		return false
+	}
+	if f.ImaDodatnuProvjeru != nil {
+		if s.ImaDodatnuProvjeru == nil || *s.ImaDodatnuProvjeru != *f.ImaDodatnuProvjeru {
+			return false
+		}
+	}
+	return true
+}
+
+// novaSkolaDetalji - podaci o školi iz prvog retka; programi se dodaju zasebno
+func novaSkolaDetalji(s models.Skola) models.SkolaDetalji {
+	return models.SkolaDetalji{
+		SkolaId:       s.SkolaId,
+		Skola:         strings.TrimSpace(s.Skola),
+		Adresa:        strings.TrimSpace(s.Adresa),
+		Mjesto:        strings.TrimSpace(s.Mjesto),
+		Zupanija:      strings.TrimSpace(s.Zupanija),
+		Lat:           s.Lat,
+		Lng:           s.Lng,
+		EMail:         s.EMail,
+		BrojTelefona:  s.BrojTelefona,
+		BrojFaksa:     s.BrojFaksa,
+		Web:           s.Web,
+		VrstaOsnivaca: strings.TrimSpace(s.VrstaOsnivaca),
+	}
+}
+
+func noviSkolaProgram(s models.Skola) models.SkolaProgram {
+	return models.SkolaProgram{
+		SkolaProgramRokId:  s.SkolaProgramRokId,
+		Program:            strings.TrimSpace(s.Program),
+		VrstaPrograma:      s.VrstaPrograma,
+		VrstaProgramaId:    s.VrstaProgramaId,
+		Kvota:              s.Kvota,
+		ParalelnaKvota:     s.ParalelnaKvota,
+		Trajanje:           s.Trajanje,
+		Prag:               s.Prag,
+		ImaDodatnuProvjeru: s.ImaDodatnuProvjeru,
+	}
+}
+
+// buildGrupeSkola - grupira programe po SkolaId, sortirano po nazivu škole
+func buildGrupeSkola(skole []models.Skola, poSkolaId map[int][]int) ([]models.SkolaDetalji, map[int]int) {
+	grupe := make([]models.SkolaDetalji, 0, len(poSkolaId))
+	for _, pozicije := range poSkolaId {
+		skola := novaSkolaDetalji(skole[pozicije[0]])
+		skola.Programi = make([]models.SkolaProgram, 0, len(pozicije))
+		for _, i := range pozicije {
+			skola.Programi = append(skola.Programi, noviSkolaProgram(skole[i]))
+		}
+		grupe = append(grupe, skola)
+	}
+
+	sort.Slice(grupe, func(i, j int) bool {
+		if grupe[i].Skola != grupe[j].Skola {
+			return grupe[i].Skola < grupe[j].Skola
+		}
+		return grupe[i].SkolaId < grupe[j].SkolaId
+	})
+
+	grupaPoId := make(map[int]int, len(grupe))
+	for i, g := range grupe {
+		grupaPoId[g.SkolaId] = i
+	}
+	return grupe, grupaPoId
+}
+
+// SkoleGrupirano - škole s programima koji zadovoljavaju filter (škole bez takvih programa se izostavljaju)
+func (idx *SkoleIndex) SkoleGrupirano(f models.SkoleFilter) []models.SkolaDetalji {
+	result := make([]models.SkolaDetalji, 0, len(idx.grupe))
+	for _, g := range idx.grupe {
+		skola := g
+		skola.Programi = nil
+		for _, i := range idx.poSkolaId[g.SkolaId] {
+			if OdgovaraFilteru(&idx.Skole[i], f) {
+				skola.Programi = append(skola.Programi, noviSkolaProgram(idx.Skole[i]))
+			}
+		}
+		if len(skola.Programi) > 0 {
+			result = append(result, skola)
+		}
+	}
+	return result
+}
+
+// SkolaPoId - jedna škola sa svim programima
+func (idx *SkoleIndex) SkolaPoId(skolaId int) (models.SkolaDetalji, bool) {
+	i, ok := idx.grupaPoId[skolaId]
+	if !ok {
+		return models.SkolaDetalji{}, false
+	}
+	return idx.grupe[i], true
+}
+
+// ProgramPoId - jedan program po SkolaProgramRokId
+func (idx *SkoleIndex) ProgramPoId(skolaProgramRokId int) (models.Skola, bool) {
+	i, ok := idx.poProgramRokId[skolaProgramRokId]
+	if !ok {
+		return models.Skola{}, false
+	}
+	return idx.Skole[i], true
+}
+
+// ProgramiPoId - programi redom kojim su ID-evi zadani; nepostojeći ID-evi se preskaču
+func (idx *SkoleIndex) ProgramiPoId(ids []int) []models.Skola {
+	result := make([]models.Skola, 0, len(ids))
+	for _, id := range ids {
+		if s, ok := idx.ProgramPoId(id); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
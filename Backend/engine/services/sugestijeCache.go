@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ddobren/eduformacije/config"
+	"github.com/ddobren/eduformacije/database"
+	"github.com/ddobren/eduformacije/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	sugestijeCachePrefix     = "sugestije:cache:odgovor:"
+	sugestijeCacheStatistika = "sugestije:cache:statistika"
+)
+
+// SugestijeCacheStatistika - brojači za GET /srednje-skole/sugestije/cache
+type SugestijeCacheStatistika struct {
+	Pogoci        int64   `json:"pogoci"`
+	Promasaji     int64   `json:"promasaji"`
+	OmjerPogodaka float64 `json:"omjerPogodaka"`
+	Zapisa        int64   `json:"zapisa"`
+	TTL           string  `json:"ttl"`
+}
+
+// sugestijeCacheTTL - trajanje zapisa (SUGESTIJE_CACHE_TTL, npr. "24h"); 0 isključuje cache
+func sugestijeCacheTTL() time.Duration {
+	ttl, err := time.ParseDuration(config.GetEnv("SUGESTIJE_CACHE_TTL", "24h"))
+	if err != nil || ttl < 0 {
+		return 24 * time.Hour
+	}
+	return ttl
+}
+
+// normaliziraniInteresi - sortirani jedinstveni stemovi bez stop riječi,
+// tako da npr. "Volim crtanje!" i "crtanje" daju isti ključ
+func normaliziraniInteresi(interesi string) string {
+	set := make(map[string]bool)
+	for _, s := range stemovi(interesi) {
+		set[s] = true
+	}
+	if len(set) == 0 {
+		return NormalizirajTekst(interesi)
+	}
+	return strings.Join(sortiraneVrijednosti(set), " ")
+}
+
+// sugestijeCacheKljuc - hash normaliziranih interesa, skupa ID-eva kandidata i verzije prompta
+func sugestijeCacheKljuc(interesi string, kandidati []models.Skola) string {
+	normalizirano := normaliziraniInteresi(interesi)
+	ids := make([]int, 0, len(kandidati))
+	for _, k := range kandidati {
+		ids = append(ids, k.SkolaProgramRokId)
+	}
+	sort.Ints(ids)
+
+	h := sha256.New()
+	h.Write([]byte(normalizirano))
+	h.Write([]byte{0})
+	h.Write([]byte(odaberiVerzijuPrompta(PromptSugestije, normalizirano)))
+	h.Write([]byte{0})
+	for _, id := range ids {
+		h.Write([]byte(strconv.Itoa(id)))
+		h.Write([]byte{','})
+	}
+	return sugestijeCachePrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// dohvatiIzCachea - vraća spremljeni AI odgovor; greške Redisa se samo logiraju
+func dohvatiIzCachea(ctx context.Context, interesi string, kandidati []models.Skola) (models.SugestijeResponse, bool) {
+	if sugestijeCacheTTL() == 0 {
+		return models.SugestijeResponse{}, false
+	}
+
+	rdb := database.GetRedisClient()
+	data, err := rdb.Get(ctx, sugestijeCacheKljuc(interesi, kandidati)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("Greška pri čitanju cachea sugestija: %v", err)
+		}
+		rdb.HIncrBy(ctx, sugestijeCacheStatistika, "promasaji", 1)
+		return models.SugestijeResponse{}, false
+	}
+
+	var resp models.SugestijeResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		log.Printf("Neispravan zapis u cacheu sugestija: %v", err)
+		rdb.HIncrBy(ctx, sugestijeCacheStatistika, "promasaji", 1)
+		return models.SugestijeResponse{}, false
+	}
+
+	rdb.HIncrBy(ctx, sugestijeCacheStatistika, "pogoci", 1)
+	resp.Meta.Cache = true
+	return resp, true
+}
+
+// spremiUCache - sprema uspješan AI odgovor (offline i rezervni odgovori se ne spremaju)
+func spremiUCache(ctx context.Context, interesi string, kandidati []models.Skola, resp models.SugestijeResponse) {
+	ttl := sugestijeCacheTTL()
+	if ttl == 0 || resp.Meta.Nacin != NacinAI || resp.Meta.Rezerva {
+		return
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	if err := database.GetRedisClient().Set(ctx, sugestijeCacheKljuc(interesi, kandidati), data, ttl).Err(); err != nil {
+		log.Printf("Greška pri spremanju u cache sugestija: %v", err)
+	}
+}
+
+// OcistiSugestijeCache - briše sve spremljene odgovore (npr. nakon učitavanja novih podataka)
+func OcistiSugestijeCache(ctx context.Context) (int, error) {
+	rdb := database.GetRedisClient()
+	obrisano := 0
+
+	iter := rdb.Scan(ctx, 0, sugestijeCachePrefix+"*", 500).Iterator()
+	var kljucevi []string
+	for iter.Next(ctx) {
+		kljucevi = append(kljucevi, iter.Val())
+		if len(kljucevi) == 500 {
+			n, err := rdb.Del(ctx, kljucevi...).Result()
+			if err != nil {
+				return obrisano, err
+			}
+			obrisano += int(n)
+			kljucevi = kljucevi[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return obrisano, err
+	}
+	if len(kljucevi) > 0 {
+		n, err := rdb.Del(ctx, kljucevi...).Result()
+		if err != nil {
+			return obrisano, err
+		}
+		obrisano += int(n)
+	}
+
+	return obrisano, nil
+}
+
+// GetSugestijeCacheStatistika - brojači pogodaka/promašaja i broj zapisa u cacheu
+func GetSugestijeCacheStatistika(ctx context.Context) (SugestijeCacheStatistika, error) {
+	rdb := database.GetRedisClient()
+
+	brojaci, err := rdb.HGetAll(ctx, sugestijeCacheStatistika).Result()
+	if err != nil {
+		return SugestijeCacheStatistika{}, err
+	}
+
+	stat := SugestijeCacheStatistika{TTL: sugestijeCacheTTL().String()}
+	stat.Pogoci, _ = strconv.ParseInt(brojaci["pogoci"], 10, 64)
+	stat.Promasaji, _ = strconv.ParseInt(brojaci["promasaji"], 10, 64)
+	if ukupno := stat.Pogoci + stat.Promasaji; ukupno > 0 {
+		stat.OmjerPogodaka = float64(stat.Pogoci) / float64(ukupno)
+	}
+
+	iter := rdb.Scan(ctx, 0, sugestijeCachePrefix+"*", 500).Iterator()
+	for iter.Next(ctx) {
+		stat.Zapisa++
+	}
+	if err := iter.Err(); err != nil {
+		return stat, err
+	}
+
+	return stat, nil
+}
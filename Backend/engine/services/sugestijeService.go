@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/ddobren/eduformacije/config"
+	"github.com/ddobren/eduformacije/models"
+)
+
+// extractTextBetweenTripleBackticks - dohvaća SAMO sadržaj
+// između prve linije s ``` i sljedeće linije s ```.
+func extractTextBetweenTripleBackticks(s string) string {
+	lines := strings.Split(s, "\n")
+	var result []string
+
+	foundOpening := false
+	for _, line := range lines {
+		trim := strings.TrimSpace(line)
+
+		// Ako linija počinje s ``` i nismo u "otvorenom" stanju
+		if strings.HasPrefix(trim, "```") && !foundOpening {
+			foundOpening = true
+			continue
+		}
+		// Ako smo unutar backtick-bloka i naiđemo opet na ```
+		if foundOpening && strings.HasPrefix(trim, "```") {
+			break
+		}
+		if foundOpening {
+			result = append(result, line)
+		}
+	}
+	if len(result) == 0 {
+		return ""
+	}
+	return strings.Join(result, "\n")
+}
+
+// parseOrFallback - pokuša parse-ati cleanJSON, inače fallback na fullText
+func parseOrFallback(cleanJSON string, fullText string, aiResp interface{}) error {
+	// 1) Ako cleanJSON nije prazan, probamo njega
+	if cleanJSON != "" {
+		if err := json.Unmarshal([]byte(cleanJSON), aiResp); err == nil {
+			// Uspjelo
+			return nil
+		}
+		// inače nastavljamo fallback
+	}
+
+	// 2) Pokušamo parse cijeli fullText
+	return json.Unmarshal([]byte(fullText), aiResp)
+}
+
+// programShema - jedan preporučeni program (zajedničko za preporuke i savjetnika)
+var programShema = &JSONShema{
+	Type:     ShemaObject,
+	Required: []string{"skolaProgramRokId", "program", "razlog", "podudaranje"},
+	Properties: map[string]*JSONShema{
+		"skolaProgramRokId": {Type: ShemaString, NePrazno: true},
+		"program":           {Type: ShemaString, NePrazno: true},
+		"razlog":            {Type: ShemaString, NePrazno: true, Description: "Jedna rečenica: zašto program odgovara interesima"},
+		"podudaranje":       {Type: ShemaNumber, Description: "Koliko program odgovara interesima, od 0 do 1"},
+	},
+}
+
+// sugestijeShema - očekivani oblik AI odgovora za preporuke
+var sugestijeShema = &JSONShema{
+	Type:     ShemaObject,
+	Required: []string{"objasnjenje", "programi"},
+	Properties: map[string]*JSONShema{
+		"objasnjenje": {Type: ShemaString, NePrazno: true, Description: "Kratko, prijateljsko objašnjenje na hrvatskom"},
+		"programi":    {Type: ShemaArray, MinItems: intPtr(1), Items: programShema},
+	},
+}
+
+func intPtr(v int) *int { return &v }
+
+// programZaPrompt - kandidat kako ga vidi AI, bez polja koja AI tek treba popuniti
+type programZaPrompt struct {
+	SkolaProgramRokId string `json:"skolaProgramRokId"`
+	Program           string `json:"program"`
+}
+
+// programiZaPrompt - svodi kandidate na parove (SkolaProgramRokId, program) koje šaljemo AI-ju
+func programiZaPrompt(kandidati []models.Skola) []programZaPrompt {
+	programi := make([]programZaPrompt, 0, len(kandidati))
+	for _, s := range kandidati {
+		programi = append(programi, programZaPrompt{
+			SkolaProgramRokId: strconv.Itoa(s.SkolaProgramRokId),
+			Program:           strings.TrimSpace(s.Program),
+		})
+	}
+	return programi
+}
+
+// sugestijePrompt - prompt za preporuke iz predloška; vraća i korištenu verziju predloška
+func sugestijePrompt(interesi string, kandidati []models.Skola) (string, string, error) {
+	verzija := odaberiVerzijuPrompta(PromptSugestije, normaliziraniInteresi(interesi))
+	prompt, err := RenderirajPrompt(PromptSugestije, verzija, SugestijePromptPodaci(interesi, kandidati))
+	return prompt, verzija, err
+}
+
+// GetRecommendations - glavni ulaz: prima interese i kandidate odabrane na serveru
+func GetRecommendations(ctx context.Context, interesi string, kandidati []models.Skola) (models.SugestijeResponse, error) {
+	// 1) Prompt s kandidatima i interesima
+	prompt, verzija, err := sugestijePrompt(interesi, kandidati)
+	if err != nil {
+		return models.SugestijeResponse{}, err
+	}
+
+	// 2) Zovemo AI u JSON načinu; odgovor se provjerava prema shemi
+	provider, err := GetLLMProvider()
+	if err != nil {
+		return models.SugestijeResponse{}, err
+	}
+
+	var aiResponse struct {
+		Objasnjenje string                 `json:"objasnjenje"`
+		Programi    []models.ProgramWithID `json:"programi"`
+	}
+	if _, err := GenerirajJSON(ctx, provider, prompt, sugestijeShema, &aiResponse); err != nil {
+		return models.SugestijeResponse{}, err
+	}
+
+	// 3) Provjera programa naspram kandidata
+	programi, meta := validirajPrograme(aiResponse.Programi, kandidati)
+
+	meta.Nacin = NacinAI
+	meta.PromptVerzija = verzija
+
+	return models.SugestijeResponse{
+		Objasnjenje: aiResponse.Objasnjenje,
+		Programi:    programi,
+		Meta:        meta,
+	}, nil
+}
+
+// Načini rada preporuka
+const (
+	NacinAI      = "ai"
+	NacinOffline = "offline"
+)
+
+// PreporuciPrograme - bira način rada (parametar ili SUGESTIJE_NACIN iz konfiguracije).
+// AI odgovori se spremaju u Redis cache (vidi sugestijeCache.go).
+// Ako AI poziv ne uspije, a SUGESTIJE_OFFLINE_REZERVA nije isključen, vraća offline preporuke.
+func PreporuciPrograme(ctx context.Context, interesi string, kandidati []models.Skola, nacin string) (models.SugestijeResponse, error) {
+	if nacin == "" {
+		nacin = config.GetEnv("SUGESTIJE_NACIN", NacinAI)
+	}
+
+	switch nacin {
+	case NacinOffline:
+		return OfflinePreporuke(interesi, kandidati), nil
+	case NacinAI:
+	default:
+		return models.SugestijeResponse{}, fmt.Errorf("nepoznat način preporuka: %s", nacin)
+	}
+
+	if resp, ok := dohvatiIzCachea(ctx, interesi, kandidati); ok {
+		return resp, nil
+	}
+
+	resp, err := GetRecommendations(ctx, interesi, kandidati)
+	if err == nil {
+		spremiUCache(ctx, interesi, kandidati, resp)
+		return resp, nil
+	}
+
+	if !koristiRezervu(err) {
+		return models.SugestijeResponse{}, err
+	}
+
+	log.Printf("AI preporuke nisu uspjele, koristim offline način: %v", err)
+	resp = OfflinePreporuke(interesi, kandidati)
+	resp.Meta.Rezerva = true
+	resp.Meta.Degradirano = AINedostupan(err)
+	return resp, nil
+}
+
+// offlineRezervaDozvoljena - smije li se pri grešci AI-ja vratiti offline preporuke
+func offlineRezervaDozvoljena() bool {
+	rezerva, err := strconv.ParseBool(config.GetEnv("SUGESTIJE_OFFLINE_REZERVA", "true"))
+	return err != nil || rezerva
+}
+
+// koristiRezervu - offline rezerva prema SUGESTIJE_OFFLINE_REZERVA; kad je circuit breaker
+// otvoren, model nije ni pozvan pa se offline odgovor vraća uvijek
+func koristiRezervu(err error) bool {
+	return errors.Is(err, ErrAINedostupan) || offlineRezervaDozvoljena()
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/ddobren/eduformacije/config"
+	"github.com/ddobren/eduformacije/models"
+)
+
+// Nazivi SSE događaja za POST /srednje-skole/sugestije/stream
+const (
+	DogadjajObjasnjenje = "objasnjenje" // {"tekst": "..."} - novi dio objašnjenja
+	DogadjajProgram     = "program"     // models.ProgramWithID - jedan provjereni program
+	DogadjajKraj        = "kraj"        // models.SugestijeResponse - cijeli odgovor
+	DogadjajGreska      = "greska"      // {"error": "..."}
+)
+
+// StreamEmiter - šalje jedan događaj klijentu
+type StreamEmiter func(dogadjaj string, podaci interface{})
+
+// emitirajCijeliOdgovor - za načine bez streama: objašnjenje i programi odjednom
+func emitirajCijeliOdgovor(resp models.SugestijeResponse, emit StreamEmiter) {
+	emit(DogadjajObjasnjenje, map[string]string{"tekst": resp.Objasnjenje})
+	for _, p := range resp.Programi {
+		emit(DogadjajProgram, p)
+	}
+}
+
+// StreamPreporuke - kao PreporuciPrograme, ali objašnjenje i provjerene programe šalje čim stignu od modela.
+// Vraća cijeli odgovor koji handler na kraju šalje kao DogadjajKraj.
+func StreamPreporuke(ctx context.Context, interesi string, kandidati []models.Skola, nacin string, emit StreamEmiter) (models.SugestijeResponse, error) {
+	if nacin == "" {
+		nacin = config.GetEnv("SUGESTIJE_NACIN", NacinAI)
+	}
+
+	provider, err := GetLLMProvider()
+	if err != nil {
+		return models.SugestijeResponse{}, err
+	}
+	streamProvider, podrzava := provider.(LLMStreamProvider)
+
+	if nacin != NacinAI || !podrzava {
+		resp, err := PreporuciPrograme(ctx, interesi, kandidati, nacin)
+		if err != nil {
+			return resp, err
+		}
+		emitirajCijeliOdgovor(resp, emit)
+		return resp, nil
+	}
+
+	if resp, ok := dohvatiIzCachea(ctx, interesi, kandidati); ok {
+		emitirajCijeliOdgovor(resp, emit)
+		return resp, nil
+	}
+
+	prompt, verzija, err := sugestijePrompt(interesi, kandidati)
+	if err != nil {
+		return models.SugestijeResponse{}, err
+	}
+
+	parser := &sugestijeStreamParser{}
+	validator := noviProgramValidator(kandidati)
+	var objasnjenje strings.Builder
+	programi := []models.ProgramWithID{}
+
+	llmResp, err := streamProvider.GenerateStream(ctx, LLMRequest{Prompt: prompt, Shema: sugestijeShema}, func(chunk string) {
+		for _, d := range parser.Dodaj(chunk) {
+			if d.Objasnjenje != "" {
+				objasnjenje.WriteString(d.Objasnjenje)
+				emit(DogadjajObjasnjenje, map[string]string{"tekst": d.Objasnjenje})
+			}
+			if d.Program != nil {
+				if p, ok := validator.Provjeri(*d.Program); ok {
+					programi = append(programi, p)
+					emit(DogadjajProgram, p)
+				}
+			}
+		}
+	})
+	if err != nil {
+		// Ako klijent još ništa nije dobio, možemo prijeći na offline preporuke
+		if objasnjenje.Len() == 0 && len(programi) == 0 && koristiRezervu(err) {
+			log.Printf("AI stream nije uspio, koristim offline način: %v", err)
+			resp := OfflinePreporuke(interesi, kandidati)
+			resp.Meta.Rezerva = true
+			resp.Meta.Degradirano = AINedostupan(err)
+			emitirajCijeliOdgovor(resp, emit)
+			return resp, nil
+		}
+		return models.SugestijeResponse{}, err
+	}
+	ZabiljeziPotrosnju(ctx, provider.Naziv(), llmResp.Potrosnja)
+
+	// Završna provjera cijelog odgovora; programi su već poslani pa se odgovor samo logira
+	var aiResponse struct {
+		Objasnjenje string                 `json:"objasnjenje"`
+		Programi    []models.ProgramWithID `json:"programi"`
+	}
+	if err := dekodirajIValidiraj(llmResp.Tekst, sugestijeShema, &aiResponse); err != nil {
+		log.Printf("AI stream (%s): odgovor ne odgovara shemi: %v", provider.Naziv(), err)
+		if len(programi) == 0 {
+			return models.SugestijeResponse{}, &AIOdgovorError{Provider: provider.Naziv(), Pokusaji: 1, Razlog: err}
+		}
+	}
+
+	meta := validator.Meta()
+	meta.Nacin = NacinAI
+	meta.PromptVerzija = verzija
+
+	resp := models.SugestijeResponse{
+		Objasnjenje: objasnjenje.String(),
+		Programi:    programi,
+		Meta:        meta,
+	}
+	spremiUCache(ctx, interesi, kandidati, resp)
+	return resp, nil
+}
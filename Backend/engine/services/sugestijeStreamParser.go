@@ -0,0 +1,172 @@
+package services
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ddobren/eduformacije/models"
+)
+
+// sugestijeStreamParser - inkrementalno čita JSON odgovor oblika
+// {"objasnjenje": "...", "programi": [{...}, ...]} dok stiže u dijelovima.
+// Vraća nove dijelove objašnjenja i programe čim je pojedini objekt zatvoren.
+type sugestijeStreamParser struct {
+	buf []byte
+	pos int
+
+	dubina       int
+	uStringu     bool
+	escape       bool
+	pocetakStr   int
+	ocekujeKljuc bool   // na dubini 1 sljedeći string je ključ
+	kljuc        string // zadnji ključ na dubini 1
+
+	uObjasnjenju       bool
+	objasnjenjePoslano int // broj već poslanih znakova (runa) dekodiranog objašnjenja
+	pocetakObjekta     int
+}
+
+// streamDogadjaj - jedan događaj parsera: dio objašnjenja ili cijeli program
+type streamDogadjaj struct {
+	Objasnjenje string
+	Program     *models.ProgramWithID
+}
+
+// Dodaj - dodaje novi dio teksta i vraća događaje koje je moguće emitirati
+func (p *sugestijeStreamParser) Dodaj(chunk string) []streamDogadjaj {
+	p.buf = append(p.buf, chunk...)
+	var dogadjaji []streamDogadjaj
+
+	for ; p.pos < len(p.buf); p.pos++ {
+		ch := p.buf[p.pos]
+
+		if p.uStringu {
+			switch {
+			case p.escape:
+				p.escape = false
+			case ch == '\\':
+				p.escape = true
+			case ch == '"':
+				p.uStringu = false
+				p.zatvoriString(&dogadjaji)
+			}
+			continue
+		}
+
+		switch ch {
+		case '"':
+			p.uStringu = true
+			p.pocetakStr = p.pos + 1
+			if p.dubina == 1 && !p.ocekujeKljuc && p.kljuc == "objasnjenje" {
+				p.uObjasnjenju = true
+			}
+		case '{', '[':
+			p.dubina++
+			if p.dubina == 1 {
+				p.ocekujeKljuc = true
+			}
+			if ch == '{' && p.dubina == 3 && p.kljuc == "programi" {
+				p.pocetakObjekta = p.pos
+			}
+		case '}', ']':
+			if ch == '}' && p.dubina == 3 && p.kljuc == "programi" {
+				var program models.ProgramWithID
+				if err := json.Unmarshal(p.buf[p.pocetakObjekta:p.pos+1], &program); err == nil {
+					dogadjaji = append(dogadjaji, streamDogadjaj{Program: &program})
+				}
+			}
+			p.dubina--
+		case ':':
+			if p.dubina == 1 {
+				p.ocekujeKljuc = false
+			}
+		case ',':
+			if p.dubina == 1 {
+				p.ocekujeKljuc = true
+			}
+		}
+	}
+
+	// Objašnjenje šaljemo i dok string još nije zatvoren
+	if p.uObjasnjenju {
+		p.posaljiObjasnjenje(p.buf[p.pocetakStr:p.pos], &dogadjaji)
+	}
+
+	return dogadjaji
+}
+
+func (p *sugestijeStreamParser) zatvoriString(dogadjaji *[]streamDogadjaj) {
+	raw := p.buf[p.pocetakStr:p.pos]
+	if p.uObjasnjenju {
+		p.posaljiObjasnjenje(raw, dogadjaji)
+		p.uObjasnjenju = false
+		return
+	}
+	if p.dubina == 1 && p.ocekujeKljuc {
+		var kljuc string
+		if err := json.Unmarshal(append(append([]byte{'"'}, raw...), '"'), &kljuc); err == nil {
+			p.kljuc = kljuc
+		}
+	}
+}
+
+// posaljiObjasnjenje - dekodira dosad primljeni dio stringa i šalje samo novi sufiks
+func (p *sugestijeStreamParser) posaljiObjasnjenje(raw []byte, dogadjaji *[]streamDogadjaj) {
+	znakovi := []rune(dekodirajDjelomicniString(raw))
+	if len(znakovi) <= p.objasnjenjePoslano {
+		return
+	}
+	*dogadjaji = append(*dogadjaji, streamDogadjaj{Objasnjenje: string(znakovi[p.objasnjenjePoslano:])})
+	p.objasnjenjePoslano = len(znakovi)
+}
+
+// dekodirajDjelomicniString - dekodira JSON string bez navodnika koji možda završava usred
+// escape sekvence, između dvije polovice UTF-16 surogatnog para ili usred višebajtnog UTF-8 znaka
+func dekodirajDjelomicniString(raw []byte) string {
+	s := string(raw)
+
+	// Odrežemo nedovršen UTF-8 znak na kraju; ostatak stiže u sljedećem dijelu
+	for n := 1; n <= utf8.UTFMax && n <= len(s); n++ {
+		if utf8.RuneStart(s[len(s)-n]) {
+			if !utf8.FullRuneInString(s[len(s)-n:]) {
+				s = s[:len(s)-n]
+			}
+			break
+		}
+	}
+
+	// Odrežemo nedovršenu escape sekvencu na kraju (\ ili \uXXX)
+	if i := strings.LastIndex(s, `\`); i >= 0 {
+		zavrsena := !escapeNaPoziciji(s, i) ||
+			(i+1 < len(s) && s[i+1] != 'u') ||
+			(i+1 < len(s) && s[i+1] == 'u' && len(s)-i >= 6)
+		if !zavrsena {
+			s = s[:i]
+		}
+	}
+
+	// Odrežemo i završen \uD800-\uDBFF na kraju: to je prva polovica surogatnog para (npr. emoji)
+	// i bez druge bi se dekodirala kao U+FFFD
+	if i := len(s) - 6; i >= 0 && s[i] == '\\' && s[i+1] == 'u' && escapeNaPoziciji(s, i) {
+		if v, err := strconv.ParseUint(s[i+2:], 16, 16); err == nil && v >= 0xD800 && v <= 0xDBFF {
+			s = s[:i]
+		}
+	}
+
+	var tekst string
+	if err := json.Unmarshal([]byte(`"`+s+`"`), &tekst); err != nil {
+		return ""
+	}
+	return tekst
+}
+
+// escapeNaPoziciji - je li kosa crta na poziciji i početak escape sekvence (neparan broj kosih crta)
+func escapeNaPoziciji(s string, i int) bool {
+	kosih := 0
+	for j := i; j >= 0 && s[j] == '\\'; j-- {
+		kosih++
+	}
+	return kosih%2 == 1
+}
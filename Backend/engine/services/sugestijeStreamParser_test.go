@@ -0,0 +1,114 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+const streamProgrami = `"programi": [` +
+	`{"skolaProgramRokId": "1001", "program": "Grafički tehničar", "razlog": "crtanje {i} dizajn", "podudaranje": 0.9},` +
+	`{"skolaProgramRokId": "1003", "program": "Tehničar za računalstvo", "podudaranje": 0.7}]}`
+
+func TestSugestijeStreamParserDijelovi(t *testing.T) {
+	slucajevi := []struct {
+		naziv       string
+		odgovor     string
+		objasnjenje string
+	}{
+		{
+			naziv:       "escape sekvence",
+			odgovor:     `{"objasnjenje": "Voli\u0161 \"crtanje\" i ra\u010dunala\nEvo prijedloga.", ` + streamProgrami,
+			objasnjenje: "Voliš \"crtanje\" i računala\nEvo prijedloga.",
+		},
+		{
+			naziv:       "emoji kao surogatni par",
+			odgovor:     `{"objasnjenje": "Crtanje \ud83c\udfa8 i boje", ` + streamProgrami,
+			objasnjenje: "Crtanje 🎨 i boje",
+		},
+		{
+			naziv:       "UTF-8 znakovi",
+			odgovor:     `{"objasnjenje": "Voliš \"crtanje\" i računala 🎨\nEvo prijedloga.", ` + streamProgrami,
+			objasnjenje: "Voliš \"crtanje\" i računala 🎨\nEvo prijedloga.",
+		},
+	}
+	ocekivaniProgrami := []string{"1001 Grafički tehničar", "1003 Tehničar za računalstvo"}
+
+	for _, s := range slucajevi {
+		for _, velicina := range []int{1, 2, 3, 7, 16, len(s.odgovor)} {
+			var p sugestijeStreamParser
+			var objasnjenje strings.Builder
+			var programi []string
+
+			for i := 0; i < len(s.odgovor); i += velicina {
+				kraj := min(i+velicina, len(s.odgovor))
+				for _, d := range p.Dodaj(s.odgovor[i:kraj]) {
+					objasnjenje.WriteString(d.Objasnjenje)
+					if d.Program != nil {
+						programi = append(programi, d.Program.SkolaProgramRokId+" "+d.Program.Program)
+					}
+				}
+			}
+
+			if objasnjenje.String() != s.objasnjenje {
+				t.Errorf("%s, dijelovi po %d: objašnjenje = %q, očekivano %q", s.naziv, velicina, objasnjenje.String(), s.objasnjenje)
+			}
+			if strings.Join(programi, "|") != strings.Join(ocekivaniProgrami, "|") {
+				t.Errorf("%s, dijelovi po %d: programi = %v, očekivano %v", s.naziv, velicina, programi, ocekivaniProgrami)
+			}
+		}
+	}
+}
+
+func TestSugestijeStreamParserProgramPrijeZatvaranja(t *testing.T) {
+	var p sugestijeStreamParser
+	p.Dodaj(`{"objasnjenje": "ok", "programi": [{"skolaProgramRokId": "1", "program": "Kuhar"}`)
+
+	dogadjaji := p.Dodaj(`, {"skolaProgramRokId": "2", "program": "Kon`)
+	if len(dogadjaji) != 0 {
+		t.Fatalf("nedovršeni program ne smije biti emitiran: %+v", dogadjaji)
+	}
+	dogadjaji = p.Dodaj(`obar"}]}`)
+	if len(dogadjaji) != 1 || dogadjaji[0].Program == nil || dogadjaji[0].Program.Program != "Konobar" {
+		t.Fatalf("očekivan program Konobar, dobiveno %+v", dogadjaji)
+	}
+}
+
+func TestSugestijeStreamParserSurogatniParIzmeduDijelova(t *testing.T) {
+	var p sugestijeStreamParser
+	var objasnjenje strings.Builder
+	for _, dio := range []string{`{"objasnjenje": "boje \ud83c`, `\udfa8 i `, `kist"}`} {
+		for _, d := range p.Dodaj(dio) {
+			objasnjenje.WriteString(d.Objasnjenje)
+		}
+	}
+	if ocek := "boje 🎨 i kist"; objasnjenje.String() != ocek {
+		t.Errorf("objašnjenje = %q, očekivano %q", objasnjenje.String(), ocek)
+	}
+}
+
+func TestDekodirajDjelomicniString(t *testing.T) {
+	slucajevi := []struct {
+		raw  string
+		ocek string
+	}{
+		{`obično`, "obično"},
+		{`red\nnovi`, "red\nnovi"},
+		{`navodnik \"`, `navodnik "`},
+		{`kraj \`, "kraj "},
+		{`kosa crta \\`, `kosa crta \`},
+		{`nedovršeno \u01`, "nedovršeno "},
+		{`unicode š`, "unicode š"},
+		{"pola znaka ra\xc4", "pola znaka ra"},
+		{"pola emojija \xf0\x9f\x8e", "pola emojija "},
+		{`\u`, ""},
+		{`pola para \ud83c`, "pola para "},
+		{`cijeli par \ud83c\udfa8`, "cijeli par 🎨"},
+		{`kosa crta \\ud83c`, `kosa crta \ud83c`},
+		{``, ""},
+	}
+	for _, s := range slucajevi {
+		if dobiveno := dekodirajDjelomicniString([]byte(s.raw)); dobiveno != s.ocek {
+			t.Errorf("dekodirajDjelomicniString(%q) = %q, očekivano %q", s.raw, dobiveno, s.ocek)
+		}
+	}
+}
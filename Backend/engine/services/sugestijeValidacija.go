@@ -0,0 +1,132 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ddobren/eduformacije/models"
+)
+
+// programValidator - provjerava programe koje vraća AI naspram skupa kandidata.
+// Nepoznate ID-eve pokušava popraviti prema nazivu programa, a ako ne uspije, odbacuje ih.
+// Nazive ispravlja na kanonski zapis i uklanja duplikate. Može se koristiti i dok odgovor stiže postupno.
+type programValidator struct {
+	poId        map[string]*models.Skola
+	poNazivu    map[string][]*models.Skola
+	iskoristeni map[string]bool
+	meta        models.SugestijeMeta
+	ukupno      int
+}
+
+func noviProgramValidator(kandidati []models.Skola) *programValidator {
+	v := &programValidator{
+		poId:        make(map[string]*models.Skola, len(kandidati)),
+		poNazivu:    make(map[string][]*models.Skola),
+		iskoristeni: make(map[string]bool),
+	}
+	for i := range kandidati {
+		s := &kandidati[i]
+		v.poId[strconv.Itoa(s.SkolaProgramRokId)] = s
+		naziv := NormalizirajTekst(s.Program)
+		v.poNazivu[naziv] = append(v.poNazivu[naziv], s)
+	}
+	return v
+}
+
+// Provjeri - vraća ispravljeni program ili false ako ga treba odbaciti
+func (v *programValidator) Provjeri(p models.ProgramWithID) (models.ProgramWithID, bool) {
+	v.ukupno++
+	id := strings.TrimSpace(p.SkolaProgramRokId)
+	kandidat, ok := v.poId[id]
+
+	if !ok {
+		// Nepoznat ID - tražimo neiskorišteni program istog naziva
+		for _, s := range v.poNazivu[NormalizirajTekst(p.Program)] {
+			if !v.iskoristeni[strconv.Itoa(s.SkolaProgramRokId)] {
+				kandidat = s
+				break
+			}
+		}
+		if kandidat == nil {
+			log.Printf("AI validacija: odbacujem nepoznati program (ID %q, %q)", p.SkolaProgramRokId, p.Program)
+			v.meta.Odbaceno++
+			return p, false
+		}
+		log.Printf("AI validacija: ID %q popravljen na %d prema nazivu %q", p.SkolaProgramRokId, kandidat.SkolaProgramRokId, p.Program)
+		id = strconv.Itoa(kandidat.SkolaProgramRokId)
+		v.meta.Ispravljeno++
+	} else if p.Program != strings.TrimSpace(kandidat.Program) {
+		v.meta.Ispravljeno++
+	}
+
+	if v.iskoristeni[id] {
+		v.meta.Duplikati++
+		return p, false
+	}
+	v.iskoristeni[id] = true
+
+	p.SkolaProgramRokId = id
+	p.Program = strings.TrimSpace(kandidat.Program)
+	p.Razlog = strings.TrimSpace(p.Razlog)
+	p.Podudaranje = math.Round(math.Min(math.Max(p.Podudaranje, 0), 1)*1000) / 1000
+	p.Oznake = oznakePrograma(kandidat)
+	return p, true
+}
+
+// oznakePrograma - kratke oznake iz skupa podataka (ne od AI-ja), npr. "prijemni ispit", "4 godine"
+func oznakePrograma(s *models.Skola) []string {
+	var oznake []string
+	if s.ImaDodatnuProvjeru != nil && *s.ImaDodatnuProvjeru {
+		oznake = append(oznake, "prijemni ispit")
+	}
+	if s.Trajanje > 0 {
+		oznake = append(oznake, trajanjeOznaka(s.Trajanje))
+	}
+	if s.Prag != nil && *s.Prag > 0 {
+		oznake = append(oznake, fmt.Sprintf("prag %d bodova", *s.Prag))
+	}
+	osnivac := NormalizirajTekst(s.VrstaOsnivaca)
+	switch {
+	case strings.HasPrefix(osnivac, "privatn"):
+		oznake = append(oznake, "privatna škola")
+	case strings.Contains(osnivac, "vjersk"):
+		oznake = append(oznake, "vjerska škola")
+	}
+	return oznake
+}
+
+// trajanjeOznaka - "1 godina", "3 godine", "5 godina"
+func trajanjeOznaka(godina int) string {
+	switch {
+	case godina%10 == 1 && godina%100 != 11:
+		return fmt.Sprintf("%d godina", godina)
+	case godina%10 >= 2 && godina%10 <= 4 && (godina%100 < 12 || godina%100 > 14):
+		return fmt.Sprintf("%d godine", godina)
+	default:
+		return fmt.Sprintf("%d godina", godina)
+	}
+}
+
+// Meta - statistika provjere; logira sažetak ako je bilo ispravaka
+func (v *programValidator) Meta() models.SugestijeMeta {
+	if v.meta.Ispravljeno > 0 || v.meta.Odbaceno > 0 || v.meta.Duplikati > 0 {
+		log.Printf("AI validacija: ispravljeno %d, odbačeno %d, duplikata %d (od %d programa)",
+			v.meta.Ispravljeno, v.meta.Odbaceno, v.meta.Duplikati, v.ukupno)
+	}
+	return v.meta
+}
+
+// validirajPrograme - provjerava cijelu listu programa odjednom
+func validirajPrograme(aiProgrami []models.ProgramWithID, kandidati []models.Skola) ([]models.ProgramWithID, models.SugestijeMeta) {
+	v := noviProgramValidator(kandidati)
+	result := make([]models.ProgramWithID, 0, len(aiProgrami))
+	for _, p := range aiProgrami {
+		if ispravljen, ok := v.Provjeri(p); ok {
+			result = append(result, ispravljen)
+		}
+	}
+	return result, v.Meta()
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/ddobren/eduformacije/models"
+)
+
+var validacijaKandidati = []models.Skola{
+	{SkolaProgramRokId: 1001, Program: "Grafički tehničar "},
+	{SkolaProgramRokId: 1002, Program: "Kuhar"},
+	{SkolaProgramRokId: 1003, Program: "Kuhar"}, // isti program u drugoj školi
+	{SkolaProgramRokId: 1004, Program: "Tehničar za računalstvo"},
+}
+
+func TestValidirajPrograme(t *testing.T) {
+	slucajevi := []struct {
+		naziv string
+		ai    []models.ProgramWithID
+		ocek  []string // SkolaProgramRokId + " " + Program
+		meta  models.SugestijeMeta
+	}{
+		{
+			naziv: "ispravni programi",
+			ai:    []models.ProgramWithID{{SkolaProgramRokId: "1004", Program: "Tehničar za računalstvo"}},
+			ocek:  []string{"1004 Tehničar za računalstvo"},
+		},
+		{
+			naziv: "kanonski naziv",
+			ai:    []models.ProgramWithID{{SkolaProgramRokId: " 1001", Program: "graficki tehnicar"}},
+			ocek:  []string{"1001 Grafički tehničar"},
+			meta:  models.SugestijeMeta{Ispravljeno: 1},
+		},
+		{
+			naziv: "nepoznat ID popravljen prema nazivu",
+			ai:    []models.ProgramWithID{{SkolaProgramRokId: "9999", Program: "Tehničar za računalstvo"}},
+			ocek:  []string{"1004 Tehničar za računalstvo"},
+			meta:  models.SugestijeMeta{Ispravljeno: 1},
+		},
+		{
+			naziv: "isti naziv popravlja se na neiskorišteni program",
+			ai: []models.ProgramWithID{
+				{SkolaProgramRokId: "1002", Program: "Kuhar"},
+				{SkolaProgramRokId: "5", Program: "kuhar"},
+			},
+			ocek: []string{"1002 Kuhar", "1003 Kuhar"},
+			meta: models.SugestijeMeta{Ispravljeno: 1},
+		},
+		{
+			naziv: "nepoznat program odbačen",
+			ai:    []models.ProgramWithID{{SkolaProgramRokId: "9999", Program: "Pilot"}},
+			ocek:  nil,
+			meta:  models.SugestijeMeta{Odbaceno: 1},
+		},
+		{
+			naziv: "duplikat",
+			ai: []models.ProgramWithID{
+				{SkolaProgramRokId: "1004", Program: "Tehničar za računalstvo"},
+				{SkolaProgramRokId: "1004", Program: "Tehničar za računalstvo"},
+			},
+			ocek: []string{"1004 Tehničar za računalstvo"},
+			meta: models.SugestijeMeta{Duplikati: 1},
+		},
+		{
+			naziv: "svi kuhari već iskorišteni",
+			ai: []models.ProgramWithID{
+				{SkolaProgramRokId: "1002", Program: "Kuhar"},
+				{SkolaProgramRokId: "1003", Program: "Kuhar"},
+				{SkolaProgramRokId: "7", Program: "Kuhar"},
+			},
+			ocek: []string{"1002 Kuhar", "1003 Kuhar"},
+			meta: models.SugestijeMeta{Odbaceno: 1},
+		},
+	}
+
+	for _, s := range slucajevi {
+		t.Run(s.naziv, func(t *testing.T) {
+			programi, meta := validirajPrograme(s.ai, validacijaKandidati)
+
+			var dobiveno []string
+			for _, p := range programi {
+				dobiveno = append(dobiveno, p.SkolaProgramRokId+" "+p.Program)
+			}
+			if len(dobiveno) != len(s.ocek) {
+				t.Fatalf("programi = %q, očekivano %q", dobiveno, s.ocek)
+			}
+			for i := range dobiveno {
+				if dobiveno[i] != s.ocek[i] {
+					t.Errorf("programi[%d] = %q, očekivano %q", i, dobiveno[i], s.ocek[i])
+				}
+			}
+			if meta != s.meta {
+				t.Errorf("meta = %+v, očekivano %+v", meta, s.meta)
+			}
+		})
+	}
+}
+
+func TestValidirajProgramePodudaranjeIRazlog(t *testing.T) {
+	slucajevi := []struct {
+		podudaranje float64
+		ocek        float64
+	}{
+		{0.8567, 0.857},
+		{1.4, 1},
+		{-0.2, 0},
+		{0, 0},
+	}
+	for _, s := range slucajevi {
+		programi, _ := validirajPrograme([]models.ProgramWithID{
+			{SkolaProgramRokId: "1004", Program: "Tehničar za računalstvo", Razlog: "  voli računala ", Podudaranje: s.podudaranje},
+		}, validacijaKandidati)
+		if len(programi) != 1 {
+			t.Fatalf("očekivan jedan program, dobiveno %+v", programi)
+		}
+		if programi[0].Podudaranje != s.ocek {
+			t.Errorf("podudaranje %v = %v, očekivano %v", s.podudaranje, programi[0].Podudaranje, s.ocek)
+		}
+		if programi[0].Razlog != "voli računala" {
+			t.Errorf("razlog = %q", programi[0].Razlog)
+		}
+	}
+}
+
+func TestOznakePrograma(t *testing.T) {
+	da, ne := true, false
+	prag := 420
+	nula := 0
+
+	slucajevi := []struct {
+		naziv string
+		skola models.Skola
+		ocek  []string
+	}{
+		{"bez podataka", models.Skola{}, nil},
+		{"prijemni i trajanje", models.Skola{ImaDodatnuProvjeru: &da, Trajanje: 4}, []string{"prijemni ispit", "4 godine"}},
+		{"bez prijemnog", models.Skola{ImaDodatnuProvjeru: &ne, Trajanje: 3}, []string{"3 godine"}},
+		{"prag", models.Skola{Prag: &prag}, []string{"prag 420 bodova"}},
+		{"prag nula se ne prikazuje", models.Skola{Prag: &nula}, nil},
+		{"privatna", models.Skola{VrstaOsnivaca: "Privatna"}, []string{"privatna škola"}},
+		{"vjerska", models.Skola{VrstaOsnivaca: "Vjerska zajednica"}, []string{"vjerska škola"}},
+		{"javna", models.Skola{VrstaOsnivaca: "Jedinica lokalne samouprave", Trajanje: 5}, []string{"5 godina"}},
+	}
+	for _, s := range slucajevi {
+		t.Run(s.naziv, func(t *testing.T) {
+			dobiveno := oznakePrograma(&s.skola)
+			if len(dobiveno) != len(s.ocek) {
+				t.Fatalf("oznake = %q, očekivano %q", dobiveno, s.ocek)
+			}
+			for i := range dobiveno {
+				if dobiveno[i] != s.ocek[i] {
+					t.Errorf("oznake[%d] = %q, očekivano %q", i, dobiveno[i], s.ocek[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTrajanjeOznaka(t *testing.T) {
+	slucajevi := map[int]string{
+		1:  "1 godina",
+		2:  "2 godine",
+		3:  "3 godine",
+		4:  "4 godine",
+		5:  "5 godina",
+		11: "11 godina",
+		12: "12 godina",
+		21: "21 godina",
+		22: "22 godine",
+	}
+	for godina, ocek := range slucajevi {
+		if dobiveno := trajanjeOznaka(godina); dobiveno != ocek {
+			t.Errorf("trajanjeOznaka(%d) = %q, očekivano %q", godina, dobiveno, ocek)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// dijakritici - zamjena hrvatskih dijakritičkih znakova (nakon lowercase); ista se koristi za upit i index
+var dijakritici = strings.NewReplacer(
+	"č", "c",
+	"ć", "c",
+	// "dj" je uobičajeni zapis za "đ" kad korisnik nema hrvatsku tipkovnicu; obrnuto se ne
+	// zamjenjuje jer "dj" postoji i u riječima bez "đ" (odjel, podjela)
+	"đ", "dj",
+	"dž", "dz",
+	"š", "s",
+	"ž", "z",
+)
+
+// NormalizirajTekst - lowercase, bez dijakritika, samo slova/brojke odvojene jednim razmakom
+func NormalizirajTekst(s string) string {
+	s = dijakritici.Replace(strings.ToLower(s))
+
+	var b strings.Builder
+	razmak := true
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			razmak = false
+			continue
+		}
+		if !razmak {
+			b.WriteRune(' ')
+			razmak = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// tokeniziraj - normalizira tekst i dijeli ga na riječi
+func tokeniziraj(s string) []string {
+	return strings.Fields(NormalizirajTekst(s))
+}
+
+// levenshtein - udaljenost uređivanja; prekida čim udaljenost prijeđe max
+func levenshtein(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > max || -d > max {
+		return max + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		najmanji := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			najmanji = min(najmanji, curr[j])
+		}
+		if najmanji > max {
+			return max + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxDuljinaInteresa - najveća dozvoljena duljina polja interesi (u znakovima)
+const MaxDuljinaInteresa = 500
+
+// Šifre razloga odbijanja unosa (za logove i frontend)
+const (
+	GuardPredugo      = "predugo"
+	GuardInjekcija    = "injekcija"
+	GuardNeprimjereno = "neprimjereno"
+)
+
+// GuardError - unos je odbijen; Poruka je prijateljski tekst za korisnika,
+// a Detalj (koje pravilo je okinuto) ide samo u log.
+type GuardError struct {
+	Razlog string
+	Poruka string
+	Detalj string
+}
+
+func (e *GuardError) Error() string {
+	return e.Poruka
+}
+
+// guardUzorak - regex nad normaliziranim tekstom (mala slova, bez dijakritika)
+type guardUzorak struct {
+	naziv string
+	re    *regexp.Regexp
+}
+
+// uzorciInjekcije - pokušaji da se modelu podmetnu nove upute (hrvatski i engleski)
+var uzorciInjekcije = []guardUzorak{
+	{"ignoriraj-upute", regexp.MustCompile(`\b(ignorir\w*|zanemar\w*|zaboravi\w*|preskoci)\b( \w+){0,3} (prethodn|prijasnj|gornj|sv[ae]|dosadasnj)\w*( \w+){0,2} (uput|pravil|instrukcij|naredb)`)},
+	{"ignore-instructions", regexp.MustCompile(`\b(ignore|disregard|forget|override)\b( \w+){0,3} (instructions?|rules|prompt|guidelines)\b`)},
+	{"nova-uloga", regexp.MustCompile(`\b(ti si sada|od sada si|ponasaj se kao|pretvaraj se da|you are now|act as|pretend to be|roleplay)\b`)},
+	{"sistemski-prompt", regexp.MustCompile(`\b(system prompt|sistemsk\w* (prompt|upute)|developer mode|jailbreak|dan mode)\b`)},
+	{"ispis-prompta", regexp.MustCompile(`\b(ispisi|pokazi|otkrij|print|reveal|show)\b( \w+){0,3} (prompt|upute|instrukcij\w*|instructions)\b`)},
+	{"json-struktura", regexp.MustCompile(`\bskolaprogramrokid\b`)},
+}
+
+// neprimjereneRijeci - psovke i uvrede (normalizirani oblici); provjerava se početak riječi
+var neprimjereneRijeci = []string{
+	"jebe", "jebo", "jebi", "jeba", "jeben", "pojeb", "zajeb", "najeb", "sjeb",
+	"kurac", "kurca", "kurcu", "kurcin", "picka", "picke", "picku", "pickin", "pizda", "pizde", "pizdu",
+	"kurva", "kurve", "kurvin", "drolj", "peder", "govno", "govna", "govnar",
+	"sranje", "usran", "posran", "debil", "fuck", "shit", "bitch",
+}
+
+var (
+	emailRegex = regexp.MustCompile(`[\p{L}\p{N}._%+\-]+@[\p{L}\p{N}.\-]+\.\p{L}{2,}`)
+	// Prva grupa je znak ispred broja, da se ne zamijeni kraj duljeg niza znamenki
+	telefonRegex = regexp.MustCompile(`(^|[^\d+])((\+|00)?(385[\s\-/]?)?\(?0?\d{1,2}\)?[\s\-/]?\d{3}[\s\-/]?\d{3,4}\b)`)
+	oibRegex     = regexp.MustCompile(`\b\d{11}\b`)
+)
+
+// ocistiKontrolneZnakove - uklanja kontrolne i nevidljive znakove (npr. zero-width) i sažima razmake
+func ocistiKontrolneZnakove(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == utf8.RuneError:
+			continue
+		case r == '\n' || r == '\t' || r == '\r':
+			b.WriteRune(' ')
+		case unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+			continue
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// ispravanOIB - kontrolna znamenka OIB-a (ISO 7064, MOD 11,10)
+func ispravanOIB(oib string) bool {
+	if len(oib) != 11 {
+		return false
+	}
+	a := 10
+	for i := 0; i < 10; i++ {
+		a = (a + int(oib[i]-'0')) % 10
+		if a == 0 {
+			a = 10
+		}
+		a = (a * 2) % 11
+	}
+	kontrolna := 11 - a
+	if kontrolna == 10 {
+		kontrolna = 0
+	}
+	return kontrolna == int(oib[10]-'0')
+}
+
+// ukloniOsobnePodatke - zamjenjuje e-mail, OIB i broj telefona oznakama; vraća i što je uklonjeno
+func ukloniOsobnePodatke(s string) (string, []string) {
+	var uklonjeno []string
+	if emailRegex.MatchString(s) {
+		s = emailRegex.ReplaceAllString(s, "[e-mail]")
+		uklonjeno = append(uklonjeno, "email")
+	}
+	oibPronaden := false
+	s = oibRegex.ReplaceAllStringFunc(s, func(m string) string {
+		if ispravanOIB(m) {
+			oibPronaden = true
+			return "[OIB]"
+		}
+		return m
+	})
+	if oibPronaden {
+		uklonjeno = append(uklonjeno, "oib")
+	}
+	if telefonRegex.MatchString(s) {
+		s = telefonRegex.ReplaceAllString(s, "${1}[telefon]")
+		uklonjeno = append(uklonjeno, "telefon")
+	}
+	return s, uklonjeno
+}
+
+// sadrziNeprimjereno - prva neprimjerena riječ u tekstu ili prazan string
+func sadrziNeprimjereno(normalizirano string) string {
+	tekst := " " + normalizirano
+	for _, r := range neprimjereneRijeci {
+		if strings.Contains(tekst, " "+r) {
+			return r
+		}
+	}
+	return ""
+}
+
+// ProvjeriUnos - čisti slobodan tekst korisnika prije slanja modelu:
+// uklanja kontrolne znakove, provjerava duljinu, odbija pokušaje podmetanja uputa i psovke,
+// a osobne podatke (e-mail, telefon, OIB) samo zamjenjuje oznakama. Prazan unos nije greška.
+func ProvjeriUnos(tekst string, maxDuljina int) (string, error) {
+	tekst = ocistiKontrolneZnakove(tekst)
+
+	if n := utf8.RuneCountInString(tekst); n > maxDuljina {
+		return "", &GuardError{
+			Razlog: GuardPredugo,
+			Poruka: fmt.Sprintf("Tekst je predugačak 🙂 Opiši svoje interese u najviše %d znakova.", maxDuljina),
+			Detalj: fmt.Sprintf("%d znakova", n),
+		}
+	}
+
+	normalizirano := NormalizirajTekst(tekst)
+
+	for _, u := range uzorciInjekcije {
+		if u.re.MatchString(normalizirano) {
+			return "", &GuardError{
+				Razlog: GuardInjekcija,
+				Poruka: "Ovdje mogu samo preporučiti škole prema tvojim interesima 🙂 Napiši što voliš raditi ili što te zanima.",
+				Detalj: u.naziv,
+			}
+		}
+	}
+
+	if rijec := sadrziNeprimjereno(normalizirano); rijec != "" {
+		return "", &GuardError{
+			Razlog: GuardNeprimjereno,
+			Poruka: "Molimo te da opišeš svoje interese bez neprimjerenih riječi 🙂",
+			Detalj: rijec,
+		}
+	}
+
+	tekst, uklonjeno := ukloniOsobnePodatke(tekst)
+	if len(uklonjeno) > 0 {
+		// Nije greška - samo bilježimo da osobni podaci nisu poslani modelu
+		log.Printf("Guard: uklonjeni osobni podaci iz unosa (%s)", strings.Join(uklonjeno, ","))
+	}
+
+	return tekst, nil
+}
+
+// ProvjeriInteresi - ProvjeriUnos s ograničenjem za polje interesi
+func ProvjeriInteresi(interesi string) (string, error) {
+	return ProvjeriUnos(interesi, MaxDuljinaInteresa)
+}
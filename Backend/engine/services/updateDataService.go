@@ -63,6 +63,13 @@ func UpdateSkoleData() error {
 		return fmt.Errorf("greška pri čitanju bodyja: %w", err)
 	}
 
+	// Prvo gradimo index - ako JSON nije valjan, zadržavamo stare podatke
+	idx, err := RebuildSkoleIndex(bodyBytes)
+	if err != nil {
+		log.Printf("Greška pri izgradnji indexa škola: %v", err)
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
 
@@ -89,6 +96,13 @@ func UpdateSkoleData() error {
 		return fmt.Errorf("podaci su spremljeni ali su prazni")
 	}
 
-	log.Printf("Podaci uspješno spremljeni u Redis (veličina: %d bytes)", len(val))
+	// Stari AI odgovori se odnose na prethodni skup programa
+	if obrisano, err := OcistiSugestijeCache(ctx); err != nil {
+		log.Printf("Greška pri brisanju cachea sugestija: %v", err)
+	} else {
+		log.Printf("Cache sugestija očišćen (%d zapisa)", obrisano)
+	}
+
+	log.Printf("Podaci uspješno spremljeni u Redis (veličina: %d bytes, verzija indexa: %d)", len(val), idx.Verzija)
 	return nil
 }
@@ -81,6 +81,10 @@ func UpdateSrednjeSkole() error {
 	}
 
 	log.Printf("Uspješno ažurirani podaci za srednje škole (%d zapisa) [MONGODB]", len(data))
+
+	if err := OsvjeziPretraguMongo("srednje"); err != nil {
+		log.Printf("Greška pri osvježavanju indexa pretrage: %v", err)
+	}
 	return nil
 }
 
@@ -127,5 +131,9 @@ func UpdateOsnovneSkole() error {
 	}
 
 	log.Printf("Uspješno ažurirani podaci za osnovne škole (%d zapisa) [MONGODB]", len(data))
+
+	if err := OsvjeziPretraguMongo("osnovne"); err != nil {
+		log.Printf("Greška pri osvježavanju indexa pretrage: %v", err)
+	}
 	return nil
 }
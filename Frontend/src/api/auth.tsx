@@ -0,0 +1,76 @@
+// Pristupni token za engine API preko anonimne sesije:
+// GET /auth/izazov -> rješavanje proof-of-work izazova -> POST /auth/sesija.
+// Token je kratkotrajan, pa se čuva u memoriji i obnavlja malo prije isteka.
+
+const AUTH_URL = "https://engine.eduformacije.com/api/v1/auth";
+const REFRESH_MARGIN_MS = 30 * 1000;
+
+interface Challenge {
+  izazov: string;
+  tezina: number;
+}
+
+let cachedToken: { value: string; expiresAt: number } | null = null;
+let pending: Promise<string> | null = null;
+
+const leadingZeroBits = (hash: Uint8Array): number => {
+  let bits = 0;
+  for (const byte of hash) {
+    if (byte === 0) {
+      bits += 8;
+      continue;
+    }
+    return bits + Math.clz32(byte) - 24;
+  }
+  return bits;
+};
+
+// Traži rješenje takvo da SHA-256(izazov + ":" + rješenje) počinje s barem "tezina" nul-bitova
+const solveChallenge = async ({ izazov, tezina }: Challenge): Promise<string> => {
+  const encoder = new TextEncoder();
+  for (let nonce = 0; ; nonce++) {
+    const digest = await crypto.subtle.digest("SHA-256", encoder.encode(`${izazov}:${nonce}`));
+    if (leadingZeroBits(new Uint8Array(digest)) >= tezina) {
+      return String(nonce);
+    }
+  }
+};
+
+const requestToken = async (): Promise<string> => {
+  const challengeResponse = await fetch(`${AUTH_URL}/izazov`);
+  if (!challengeResponse.ok) {
+    throw new Error("Greška prilikom dohvaćanja izazova");
+  }
+  const challenge: Challenge = await challengeResponse.json();
+  const rjesenje = await solveChallenge(challenge);
+
+  const response = await fetch(`${AUTH_URL}/sesija`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ izazov: challenge.izazov, rjesenje }),
+  });
+
+  if (!response.ok) {
+    throw new Error("Greška prilikom dohvaćanja pristupnog tokena");
+  }
+
+  const data: { access_token: string; expires_in: number } = await response.json();
+  cachedToken = {
+    value: data.access_token,
+    expiresAt: Date.now() + data.expires_in * 1000,
+  };
+  return data.access_token;
+};
+
+export const getAccessToken = async (): Promise<string> => {
+  if (cachedToken && cachedToken.expiresAt - REFRESH_MARGIN_MS > Date.now()) {
+    return cachedToken.value;
+  }
+  // Istovremeni pozivi dijele istu sesiju
+  if (!pending) {
+    pending = requestToken().finally(() => {
+      pending = null;
+    });
+  }
+  return pending;
+};
@@ -2,6 +2,9 @@
 export interface Program {
     skolaProgramRokId: string;
     program: string;
+    razlog?: string;
+    podudaranje?: number;
+    oznake?: string[];
   }
   
   export interface SchoolDetails {